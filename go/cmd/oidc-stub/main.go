@@ -0,0 +1,194 @@
+// Command oidc-stub is a local OpenID Connect provider for trying single
+// sign-on without a real identity provider. It signs everyone in without a
+// password: as the login_hint email when given, otherwise as STUB_EMAIL.
+//
+//	go run ./cmd/oidc-stub
+//	OIDC_ISSUER=http://localhost:9000 OIDC_CLIENT_ID=go-api \
+//	OIDC_REDIRECT_URL=http://localhost:8080/auth/oidc/callback go run .
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"go-api/keys"
+	"go-api/oidc"
+)
+
+// authorization is a code waiting to be exchanged
+type authorization struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	email         string
+}
+
+type stub struct {
+	issuer  string
+	keyring *keys.Keyring
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+type idTokenClaims struct {
+	Issuer        string `json:"iss"`
+	Subject       string `json:"sub"`
+	Audience      string `json:"aud"`
+	ExpiresAt     int64  `json:"exp"`
+	IssuedAt      int64  `json:"iat"`
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (c *idTokenClaims) Valid() error {
+	return nil
+}
+
+func main() {
+	addr := envOr("OIDC_STUB_ADDR", "localhost:9000")
+
+	// A fresh key every run; clients fetch it from the JWKS endpoint
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, err := keys.NewKey("stub", privateKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	keyring, err := keys.New(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := &stub{issuer: "http://" + addr, keyring: keyring, codes: map[string]authorization{}}
+
+	http.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	http.HandleFunc("GET /authorize", s.authorize)
+	http.HandleFunc("POST /token", s.token)
+	http.HandleFunc("GET /jwks", s.jwks)
+
+	log.Printf("Stub OIDC provider at %s", s.issuer)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+func (s *stub) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *stub) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	email := query.Get("login_hint")
+	if email == "" {
+		email = envOr("STUB_EMAIL", "employee1@example.com")
+	}
+
+	code := random()
+	s.mu.Lock()
+	s.codes[code] = authorization{
+		clientID:      query.Get("client_id"),
+		redirectURI:   redirectURI.String(),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		email:         email,
+	}
+	s.mu.Unlock()
+
+	callback := redirectURI.Query()
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	redirectURI.RawQuery = callback.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *stub) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID := r.PostForm.Get("client_id")
+	if user, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(user)
+	}
+
+	s.mu.Lock()
+	auth, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	if !ok || auth.clientID != clientID || auth.redirectURI != r.PostForm.Get("redirect_uri") ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken, err := s.keyring.Sign(&idTokenClaims{
+		Issuer:        s.issuer,
+		Subject:       auth.email,
+		Audience:      auth.clientID,
+		ExpiresAt:     now.Add(5 * time.Minute).Unix(),
+		IssuedAt:      now.Unix(),
+		Nonce:         auth.nonce,
+		Email:         auth.email,
+		EmailVerified: true,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": random(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (s *stub) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.keyring.JWKS())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func random() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
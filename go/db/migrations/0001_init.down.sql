@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS feedback;
+DROP TABLE IF EXISTS review_reviewers;
+DROP TABLE IF EXISTS reviews;
+DROP TABLE IF EXISTS employees;
+DROP TABLE IF EXISTS users;
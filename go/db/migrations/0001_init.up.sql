@@ -1,5 +1,5 @@
 -- Users Table
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     email TEXT UNIQUE NOT NULL,
     password TEXT NOT NULL,
@@ -7,14 +7,14 @@ CREATE TABLE users (
 );
 
 -- Employees Table
-CREATE TABLE employees (
+CREATE TABLE IF NOT EXISTS employees (
     id SERIAL PRIMARY KEY,
     email TEXT UNIQUE NOT NULL,
     position TEXT NOT NULL
 );
 
 -- Reviews Table
-CREATE TABLE reviews (
+CREATE TABLE IF NOT EXISTS reviews (
     id SERIAL PRIMARY KEY,
     employee_id INT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
     performance_review TEXT NOT NULL,
@@ -23,7 +23,7 @@ CREATE TABLE reviews (
 );
 
 -- Review Reviewers Table
-CREATE TABLE review_reviewers (
+CREATE TABLE IF NOT EXISTS review_reviewers (
     id SERIAL PRIMARY KEY,
     review_id INT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
     reviewer_id INT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
@@ -31,8 +31,8 @@ CREATE TABLE review_reviewers (
 );
 
 -- Feedback Table
-CREATE TABLE feedback (
+CREATE TABLE IF NOT EXISTS feedback (
     id SERIAL PRIMARY KEY,
     review_id INT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
     submitted BOOLEAN DEFAULT FALSE
-);
\ No newline at end of file
+);
@@ -0,0 +1,2 @@
+ALTER TABLE reviews DROP COLUMN IF EXISTS cycle_id;
+DROP TABLE IF EXISTS review_cycles;
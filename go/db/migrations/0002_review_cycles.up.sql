@@ -0,0 +1,35 @@
+-- Review Cycles Table
+CREATE TABLE review_cycles (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    self_review_deadline TIMESTAMP NOT NULL,
+    peer_review_deadline TIMESTAMP NOT NULL,
+    closes_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'open', 'closed')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (starts_at <= self_review_deadline),
+    CHECK (self_review_deadline <= peer_review_deadline),
+    CHECK (peer_review_deadline <= closes_at)
+);
+
+ALTER TABLE reviews ADD COLUMN cycle_id INT REFERENCES review_cycles(id) ON DELETE RESTRICT;
+
+-- Reviews created before cycles existed are filed under an open "Legacy" cycle
+-- that admins can close once outstanding feedback is in
+WITH legacy AS (
+    INSERT INTO review_cycles (name, starts_at, self_review_deadline, peer_review_deadline, closes_at, status)
+    SELECT 'Legacy',
+           COALESCE(MIN(created_at), CURRENT_TIMESTAMP),
+           CURRENT_TIMESTAMP + INTERVAL '90 days',
+           CURRENT_TIMESTAMP + INTERVAL '90 days',
+           CURRENT_TIMESTAMP + INTERVAL '90 days',
+           'open'
+    FROM reviews
+    HAVING COUNT(*) > 0
+    RETURNING id
+)
+UPDATE reviews SET cycle_id = (SELECT id FROM legacy);
+
+ALTER TABLE reviews ALTER COLUMN cycle_id SET NOT NULL;
+CREATE INDEX reviews_cycle_id_idx ON reviews (cycle_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS review_transitions;
+ALTER TABLE reviews DROP COLUMN IF EXISTS status;
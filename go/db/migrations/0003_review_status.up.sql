@@ -0,0 +1,19 @@
+ALTER TABLE reviews ADD COLUMN status TEXT NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'in_progress', 'submitted', 'shared', 'acknowledged', 'archived'));
+
+-- Existing reviews were already visible to their reviewers
+UPDATE reviews SET status = 'in_progress';
+
+CREATE INDEX reviews_status_idx ON reviews (status);
+
+-- Review Transitions Table
+CREATE TABLE review_transitions (
+    id SERIAL PRIMARY KEY,
+    review_id INT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    actor_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX review_transitions_review_id_idx ON review_transitions (review_id);
@@ -0,0 +1,22 @@
+ALTER TABLE reviews ADD COLUMN comments TEXT[] DEFAULT ARRAY[]::TEXT[];
+
+UPDATE reviews r
+SET comments = f.bodies
+FROM (
+    SELECT review_id, ARRAY_AGG(body ORDER BY id) AS bodies
+    FROM feedback
+    WHERE submitted
+    GROUP BY review_id
+) f
+WHERE f.review_id = r.id;
+
+DELETE FROM feedback;
+
+ALTER TABLE feedback
+    DROP CONSTRAINT IF EXISTS feedback_review_reviewer_key,
+    DROP COLUMN IF EXISTS reviewer_id,
+    DROP COLUMN IF EXISTS body,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE feedback ALTER COLUMN submitted DROP NOT NULL;
@@ -0,0 +1,21 @@
+-- Rows in feedback were never written by the API and carry no content
+DELETE FROM feedback;
+
+ALTER TABLE feedback
+    ADD COLUMN reviewer_id INT REFERENCES employees(id) ON DELETE CASCADE,
+    ADD COLUMN body TEXT NOT NULL DEFAULT '',
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD CONSTRAINT feedback_review_reviewer_key UNIQUE (review_id, reviewer_id);
+
+ALTER TABLE feedback ALTER COLUMN submitted SET NOT NULL;
+
+CREATE INDEX feedback_reviewer_id_idx ON feedback (reviewer_id);
+
+-- Carry over comments appended to reviews; their authors were never recorded
+INSERT INTO feedback (review_id, body, submitted, created_at, updated_at)
+SELECT r.id, c.body, TRUE, COALESCE(r.created_at, CURRENT_TIMESTAMP), COALESCE(r.created_at, CURRENT_TIMESTAMP)
+FROM reviews r, UNNEST(r.comments) WITH ORDINALITY AS c(body, position)
+ORDER BY r.id, c.position;
+
+ALTER TABLE reviews DROP COLUMN comments;
@@ -0,0 +1,2 @@
+-- Version is bumped on every write so concurrent draft saves can be detected
+ALTER TABLE feedback ADD COLUMN version INT NOT NULL DEFAULT 1;
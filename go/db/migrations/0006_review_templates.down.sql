@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS feedback_answers;
+ALTER TABLE reviews DROP COLUMN IF EXISTS template_id;
+DROP TABLE IF EXISTS template_questions;
+DROP TABLE IF EXISTS template_sections;
+DROP TABLE IF EXISTS review_templates;
@@ -0,0 +1,39 @@
+-- Admin-defined review forms made of sections of questions
+CREATE TABLE review_templates (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE template_sections (
+    id SERIAL PRIMARY KEY,
+    template_id INT NOT NULL REFERENCES review_templates(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    title TEXT NOT NULL,
+    UNIQUE (template_id, position)
+);
+
+CREATE TABLE template_questions (
+    id SERIAL PRIMARY KEY,
+    section_id INT NOT NULL REFERENCES template_sections(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    prompt TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('rating', 'choice', 'text')),
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Options offered by choice questions, empty for other types
+    options TEXT[] NOT NULL DEFAULT '{}',
+    UNIQUE (section_id, position)
+);
+
+-- Reviews without a template keep using the free-text performance_review only
+ALTER TABLE reviews ADD COLUMN template_id INT REFERENCES review_templates(id) ON DELETE RESTRICT;
+
+CREATE TABLE feedback_answers (
+    feedback_id INT NOT NULL REFERENCES feedback(id) ON DELETE CASCADE,
+    question_id INT NOT NULL REFERENCES template_questions(id) ON DELETE CASCADE,
+    rating SMALLINT CHECK (rating BETWEEN 1 AND 5),
+    choice TEXT,
+    text TEXT,
+    PRIMARY KEY (feedback_id, question_id)
+);
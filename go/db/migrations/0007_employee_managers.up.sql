@@ -0,0 +1,4 @@
+-- Reporting lines; cycles are prevented by the application when a manager is set
+ALTER TABLE employees ADD COLUMN manager_id INT REFERENCES employees(id) ON DELETE SET NULL;
+ALTER TABLE employees ADD CONSTRAINT employees_not_own_manager CHECK (manager_id <> id);
+CREATE INDEX employees_manager_id_idx ON employees (manager_id);
@@ -0,0 +1,6 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey;
+-- Users with roles the old check does not know fall back to employee
+UPDATE users SET role = 'employee' WHERE role NOT IN ('admin', 'employee');
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'employee'));
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
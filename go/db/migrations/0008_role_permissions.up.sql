@@ -0,0 +1,48 @@
+-- Roles and the permissions they grant, replacing the fixed admin/employee check
+CREATE TABLE roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+-- Keep in sync with store.DefaultRoles
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access'),
+    ('employee', 'Gives feedback and reads reviews about themselves'),
+    ('manager', 'Employee who also reviews their direct and indirect reports'),
+    ('hr_partner', 'Runs reviews for the whole company'),
+    ('auditor', 'Read-only access to employees and reviews');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'employees:read'),
+    ('admin', 'employees:manage'),
+    ('admin', 'reviews:read:any'),
+    ('admin', 'reviews:write:any'),
+    ('admin', 'reviews:read:own_reports'),
+    ('admin', 'reviews:write:own_reports'),
+    ('admin', 'reviews:read:own'),
+    ('admin', 'feedback:write'),
+    ('admin', 'cycles:manage'),
+    ('admin', 'templates:manage'),
+    ('employee', 'feedback:write'),
+    ('employee', 'reviews:read:own'),
+    ('manager', 'feedback:write'),
+    ('manager', 'reviews:read:own'),
+    ('manager', 'reviews:read:own_reports'),
+    ('manager', 'reviews:write:own_reports'),
+    ('hr_partner', 'employees:read'),
+    ('hr_partner', 'reviews:read:any'),
+    ('hr_partner', 'reviews:write:any'),
+    ('hr_partner', 'cycles:manage'),
+    ('hr_partner', 'templates:manage'),
+    ('auditor', 'employees:read'),
+    ('auditor', 'reviews:read:any');
+
+-- Users may hold any defined role
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
@@ -0,0 +1,10 @@
+-- Single-use password reset tokens; only a SHA-256 hash of each token is stored
+CREATE TABLE password_resets (
+    id SERIAL PRIMARY KEY,
+    token_hash TEXT UNIQUE NOT NULL,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX password_resets_user_id_idx ON password_resets (user_id);
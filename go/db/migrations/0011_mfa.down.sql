@@ -0,0 +1,6 @@
+ALTER TABLE roles DROP COLUMN IF EXISTS mfa_required;
+DROP TABLE IF EXISTS recovery_codes;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS totp_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,20 @@
+-- TOTP two-factor authentication. The secret is set when enrolment starts and
+-- only counts once totp_enabled is set by confirming a code.
+ALTER TABLE users
+    ADD COLUMN totp_secret TEXT,
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    -- The last time step a code was accepted for, so codes cannot be replayed
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Single-use recovery codes for users who lose their authenticator; only
+-- SHA-256 hashes are stored
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
+
+-- Roles whose users must use two-factor authentication
+ALTER TABLE roles ADD COLUMN mfa_required BOOLEAN NOT NULL DEFAULT FALSE;
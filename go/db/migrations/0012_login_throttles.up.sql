@@ -0,0 +1,9 @@
+-- Failed login attempts per account ("account:<email>") and per client IP
+-- ("ip:<address>"), shared by every replica
+CREATE TABLE login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    -- Logins are refused until then
+    locked_until TIMESTAMP NOT NULL
+);
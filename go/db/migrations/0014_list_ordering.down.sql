@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS reviews_created_at_idx;
+DROP INDEX IF EXISTS employees_created_at_idx;
+
+ALTER TABLE reviews ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE employees DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,9 @@
+-- Listings can be sorted and paged by creation time, which employees did not
+-- record and reviews left nullable
+ALTER TABLE employees ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE reviews SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+ALTER TABLE reviews ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX employees_created_at_idx ON employees (created_at, id);
+CREATE INDEX reviews_created_at_idx ON reviews (created_at, id);
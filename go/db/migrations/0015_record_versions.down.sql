@@ -0,0 +1,2 @@
+ALTER TABLE reviews DROP COLUMN IF EXISTS version;
+ALTER TABLE employees DROP COLUMN IF EXISTS version;
@@ -0,0 +1,4 @@
+-- Row versions for optimistic concurrency; every write to a row increases its
+-- version and clients send it back in If-Match
+ALTER TABLE employees ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE reviews ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS review_job_results;
+DROP TABLE IF EXISTS review_jobs;
@@ -0,0 +1,21 @@
+-- Batches of reviews created for a cycle in one request, with what was done
+-- for each selected employee
+CREATE TABLE review_jobs (
+    id SERIAL PRIMARY KEY,
+    cycle_id INT NOT NULL REFERENCES review_cycles(id) ON DELETE CASCADE,
+    template_id INT REFERENCES review_templates(id) ON DELETE SET NULL,
+    strategy TEXT NOT NULL CHECK (strategy IN ('manager_peers', 'team', 'mapping')),
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE review_job_results (
+    job_id INT NOT NULL REFERENCES review_jobs(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    employee_id INT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    -- NULL when the employee was skipped or the review was removed since
+    review_id INT REFERENCES reviews(id) ON DELETE SET NULL,
+    reviewer_ids INT[] NOT NULL DEFAULT '{}',
+    skip_reason TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (job_id, position)
+);
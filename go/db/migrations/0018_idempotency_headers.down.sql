@@ -0,0 +1,3 @@
+ALTER TABLE idempotency_keys ADD COLUMN content_type TEXT NOT NULL DEFAULT '';
+UPDATE idempotency_keys SET content_type = headers->>'Content-Type' WHERE headers->>'Content-Type' IS NOT NULL;
+ALTER TABLE idempotency_keys DROP COLUMN headers;
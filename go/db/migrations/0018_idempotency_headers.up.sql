@@ -0,0 +1,4 @@
+-- Replay every stored response header, such as ETag, not only Content-Type
+ALTER TABLE idempotency_keys ADD COLUMN headers JSONB NOT NULL DEFAULT '{}';
+UPDATE idempotency_keys SET headers = jsonb_build_object('Content-Type', content_type) WHERE content_type <> '';
+ALTER TABLE idempotency_keys DROP COLUMN content_type;
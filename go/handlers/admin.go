@@ -1,18 +1,56 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"sync"
+	"time"
 
-	"go-api/db"
+	"go-api/passwords"
+	"go-api/store"
 	"go-api/types"
-
-	"github.com/jtclarkjr/router-go"
-	"github.com/lib/pq"
+	"go-api/validate"
 )
 
+// AdminHandler serves the /admin routes
+type AdminHandler struct {
+	users     store.UserStore
+	tokens    store.TokenStore
+	throttles store.LoginThrottleStore
+	roles     store.RoleStore
+	employees store.EmployeeStore
+	reviews   store.ReviewStore
+	jobs      store.ReviewJobStore
+	cycles    store.CycleStore
+	feedback  store.FeedbackStore
+	templates store.TemplateStore
+	editor    reviewEditor
+	policy    *passwords.Policy
+	invites   *PasswordHandler
+}
+
+// NewAdminHandler creates an AdminHandler using the given stores, checking new
+// passwords against policy and emailing imported employees through invites
+func NewAdminHandler(stores store.Stores, policy *passwords.Policy, invites *PasswordHandler) *AdminHandler {
+	return &AdminHandler{
+		users:     stores.Users,
+		tokens:    stores.Tokens,
+		throttles: stores.Throttles,
+		roles:     stores.Roles,
+		employees: stores.Employees,
+		reviews:   stores.Reviews,
+		jobs:      stores.ReviewJobs,
+		cycles:    stores.Cycles,
+		feedback:  stores.Feedback,
+		templates: stores.Templates,
+		editor:    newReviewEditor(stores),
+		policy:    policy,
+		invites:   invites,
+	}
+}
+
 // /employees handlers
 
 // AddEmployee godoc
@@ -22,65 +60,52 @@ import (
 // @Accept json
 // @Produce json
 // @Param employee body object true "Employee info"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
 // @Success 201 {object} types.CreateEmployeeResponse
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
 // @Router /admin/employees [post]
-func AddEmployee(w http.ResponseWriter, r *http.Request) {
+func (h *AdminHandler) AddEmployee(w http.ResponseWriter, r *http.Request) {
 	var employee struct {
-		Email    string `json:"email"`
-		Position string `json:"position"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required,email,max=254"`
+		Position string `json:"position" validate:"required,max=100"`
+		Password string `json:"password" validate:"required"`
 	}
-	err := json.NewDecoder(r.Body).Decode(&employee)
-	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if !decodePayload(w, r, &employee) {
 		return
 	}
 
-	hashedPassword, err := hashPassword(employee.Password)
-	if err != nil {
-		http.Error(w, "Error hashing password", http.StatusInternalServerError)
-		return
-	}
-
-	tx, err := db.Conn.Begin()
-	if err != nil {
-		http.Error(w, "Error starting transaction", http.StatusInternalServerError)
+	if err := h.policy.Check(employee.Password); err != nil {
+		writeValidationErrors(w, r, validate.Errors{"password": err.Error()})
 		return
 	}
 
-	var employeeID int
-	err = tx.QueryRow(
-		"INSERT INTO employees (email, position) VALUES ($1, $2) RETURNING id",
-		employee.Email, employee.Position,
-	).Scan(&employeeID)
+	hashedPassword, err := hashPassword(employee.Password)
 	if err != nil {
-		_ = tx.Rollback()
-		http.Error(w, "Error adding employee", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error hashing password")
 		return
 	}
 
-	_, err = tx.Exec(
-		"INSERT INTO users (email, password, role) VALUES ($1, $2, 'employee')",
-		employee.Email, hashedPassword,
-	)
-	if err != nil {
-		_ = tx.Rollback()
-		http.Error(w, "Error creating user account", http.StatusInternalServerError)
+	created, err := h.employees.Create(r.Context(), store.Employee{
+		Email:    employee.Email,
+		Position: employee.Position,
+	}, hashedPassword)
+	if errors.Is(err, store.ErrEmailTaken) {
+		WriteError(w, r, http.StatusConflict, CodeEmployeeEmailTaken, "An employee or account already uses this email")
 		return
 	}
-
-	err = tx.Commit()
 	if err != nil {
-		http.Error(w, "Error committing transaction", http.StatusInternalServerError)
+		log.Printf("Error adding employee: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error adding employee")
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	err = json.NewEncoder(w).Encode(types.CreateEmployeeResponse{
-		EmployeeID: employeeID,
-		Email:      employee.Email,
+		EmployeeID: created.ID,
+		Email:      created.Email,
 	})
 	if err != nil {
 		return
@@ -89,38 +114,41 @@ func AddEmployee(w http.ResponseWriter, r *http.Request) {
 
 // GetEmployees godoc
 // @Summary Get all employees
-// @Description Retrieves a list of all employees
+// @Description Retrieves one page of employees, optionally filtered and sorted. Pass next_cursor from the response as cursor to fetch the next page.
 // @Tags Admin
 // @Produce json
-// @Success 200 {array} types.EmployeeResponse
-// @Failure 500 {string} string "Internal Server Error"
+// @Param position query string false "Only employees with this position"
+// @Param created_after query string false "Only employees added after this RFC 3339 time"
+// @Param created_before query string false "Only employees added before this RFC 3339 time"
+// @Param sort query string false "Comma-separated fields among id, email, position and created_at, each prefixed with - for descending order, e.g. created_at,-email"
+// @Param limit query int false "Employees per page, 1 to 200, default 50"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} types.EmployeeListResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
 // @Router /admin/employees [get]
-func GetEmployees(w http.ResponseWriter, r *http.Request) {
-	rows, err := db.Conn.Query("SELECT id, email, position FROM employees")
+func (h *AdminHandler) GetEmployees(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseEmployeeFilter(r)
 	if err != nil {
-		http.Error(w, "Error fetching employees", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	page, err := parsePage(r, store.EmployeeSortFields, store.Employee{})
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			log.Printf("Error closing rows: %v", err)
-		}
-	}()
 
-	var employees []types.EmployeeResponse
-	for rows.Next() {
-		var id int
-		var email, position string
-		err := rows.Scan(&id, &email, &position)
-		if err != nil {
-			http.Error(w, "Error scanning employee data", http.StatusInternalServerError)
-			return
-		}
-		employees = append(employees, types.EmployeeResponse{
-			ID:       id,
-			Email:    email,
-			Position: position,
-		})
+	stored, err := h.employees.List(r.Context(), filter, page)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching employees")
+		return
+	}
+	stored, next := nextCursor(stored, page)
+
+	employees := types.EmployeeListResponse{Items: []types.EmployeeResponse{}, NextCursor: next}
+	for _, employee := range stored {
+		employees.Items = append(employees.Items, employeeResponse(employee))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -130,293 +158,520 @@ func GetEmployees(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetEmployee godoc
+// @Summary Get an employee
+// @Description Retrieves one employee. The ETag header carries the employee's version for If-Match on later writes; send it as If-None-Match to get 304 while the employee is unchanged.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Employee ID"
+// @Param If-None-Match header string false "ETag of the version already held"
+// @Success 200 {object} types.EmployeeResponse
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/employees/{id} [get]
+func (h *AdminHandler) GetEmployee(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := pathID(w, r, "employee")
+	if !ok {
+		return
+	}
+
+	employee, err := h.employees.Get(r.Context(), employeeID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeEmployeeNotFound, "Employee not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching employee")
+		return
+	}
+	if notModified(w, r, employee.Version) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(employeeResponse(employee)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+func employeeResponse(employee store.Employee) types.EmployeeResponse {
+	return types.EmployeeResponse{
+		ID:        employee.ID,
+		Email:     employee.Email,
+		Position:  employee.Position,
+		ManagerID: employee.ManagerID,
+		Version:   employee.Version,
+		CreatedAt: employee.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// employeeUpdatePayload is the request body for updating employees
+type employeeUpdatePayload struct {
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Position string `json:"position" validate:"required,max=100"`
+}
+
 // UpdateEmployee godoc
 // @Summary Update an employee
-// @Description Updates an employee's information
+// @Description Replaces an employee's email and position
 // @Tags Admin
 // @Accept json
 // @Produce json
 // @Param id path int true "Employee ID"
 // @Param employee body object true "Employee info"
+// @Param If-Match header string false "ETag of the version last read"
 // @Success 204 {string} string "No Content"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 404 {string} string "Not Found"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
 // @Router /admin/employees/{id} [put]
-func UpdateEmployee(w http.ResponseWriter, r *http.Request) {
-	employeeID := router.URLParam(r, "id")
+func (h *AdminHandler) UpdateEmployee(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := pathID(w, r, "employee")
+	if !ok {
+		return
+	}
 
-	var employee struct {
-		Email    string `json:"email"`
-		Position string `json:"position"`
+	expectedVersion, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var employee employeeUpdatePayload
+	if !decodePayload(w, r, &employee) {
+		return
+	}
+
+	h.saveEmployee(w, r, employeeID, employee, expectedVersion)
+}
+
+// PatchEmployee godoc
+// @Summary Patch an employee
+// @Description Changes only the fields of an employee given in a JSON Merge Patch (RFC 7396), e.g. {"position": "Lead"}
+// @Tags Admin
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Employee ID"
+// @Param employee body object true "Fields to change"
+// @Param If-Match header string false "ETag of the version last read"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/employees/{id} [patch]
+func (h *AdminHandler) PatchEmployee(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := pathID(w, r, "employee")
+	if !ok {
+		return
+	}
+
+	expectedVersion, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	current, err := h.employees.Get(r.Context(), employeeID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeEmployeeNotFound, "Employee not found")
+		return
 	}
-	err := json.NewDecoder(r.Body).Decode(&employee)
 	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching employee")
+		return
+	}
+	if expectedVersion != 0 && current.Version != expectedVersion {
+		writePreconditionFailed(w, r)
+		return
+	}
+
+	employee := employeeUpdatePayload{Email: current.Email, Position: current.Position}
+	if !decodeMergePatch(w, r, &employee) {
 		return
 	}
 
-	_, err = db.Conn.Exec(
-		"UPDATE employees SET email = $1, position = $2 WHERE id = $3",
-		employee.Email, employee.Position, employeeID,
-	)
+	// The patch applies to the version just read, so a concurrent change fails it
+	h.saveEmployee(w, r, employeeID, employee, current.Version)
+}
+
+// saveEmployee stores the employee's new email and position and writes the
+// response with the ETag of the new version
+func (h *AdminHandler) saveEmployee(w http.ResponseWriter, r *http.Request, employeeID int, employee employeeUpdatePayload, expectedVersion int) {
+	version, err := h.employees.Update(r.Context(), store.Employee{
+		ID:       employeeID,
+		Email:    employee.Email,
+		Position: employee.Position,
+	}, expectedVersion)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeEmployeeNotFound, "Employee not found")
+		return
+	}
+	if errors.Is(err, store.ErrVersionMismatch) {
+		writePreconditionFailed(w, r)
+		return
+	}
+	if errors.Is(err, store.ErrEmailTaken) {
+		WriteError(w, r, http.StatusConflict, CodeEmployeeEmailTaken, "Another employee already uses this email")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error updating employee", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error updating employee")
 		return
 	}
 
+	w.Header().Set("ETag", etag(version))
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // RemoveEmployee godoc
 // @Summary Remove an employee
-// @Description Removes an employee from the system
+// @Description Removes an employee and their login account from the system, revoking every token they hold
 // @Tags Admin
 // @Param id path int true "Employee ID"
+// @Param If-Match header string false "ETag of the version last read"
 // @Success 204 {string} string "No Content"
-// @Failure 404 {string} string "Not Found"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
 // @Router /admin/employees/{id} [delete]
-func RemoveEmployee(w http.ResponseWriter, r *http.Request) {
-	employeeID := router.URLParam(r, "id")
+func (h *AdminHandler) RemoveEmployee(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := pathID(w, r, "employee")
+	if !ok {
+		return
+	}
+	expectedVersion, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	_, err := db.Conn.Exec("DELETE FROM employees WHERE id = $1", employeeID)
+	// Check the version before revoking anything; Delete checks it again
+	if expectedVersion != 0 {
+		employee, err := h.employees.Get(r.Context(), employeeID)
+		if errors.Is(err, store.ErrNotFound) {
+			WriteError(w, r, http.StatusNotFound, CodeEmployeeNotFound, "Employee not found")
+			return
+		}
+		if err != nil {
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error removing employee")
+			return
+		}
+		if employee.Version != expectedVersion {
+			writePreconditionFailed(w, r)
+			return
+		}
+	}
+
+	// Revoke the sessions first: the refresh tokens that record the access
+	// tokens go away with the login account
+	if err := h.revokeEmployeeTokens(r.Context(), employeeID); err != nil {
+		log.Printf("Error revoking tokens of employee %d: %v", employeeID, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error removing employee")
+		return
+	}
+
+	err := h.employees.Delete(r.Context(), employeeID, expectedVersion)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeEmployeeNotFound, "Employee not found")
+		return
+	}
+	if errors.Is(err, store.ErrVersionMismatch) {
+		writePreconditionFailed(w, r)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error removing employee", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error removing employee")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// revokeEmployeeTokens revokes every token of the employee's login account
+func (h *AdminHandler) revokeEmployeeTokens(ctx context.Context, employeeID int) error {
+	employee, err := h.employees.Get(ctx, employeeID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	user, err := h.users.GetByEmail(ctx, employee.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return h.tokens.RevokeUser(ctx, user.ID)
+}
+
 // /review handlers
 
 // AddReview godoc
 // @Summary Add a new review
-// @Description Creates a new performance review and assigns reviewers
+// @Description Creates a new performance review in the draft status and assigns reviewers
 // @Tags Admin
 // @Accept json
 // @Produce json
 // @Param review body object true "Review info"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
 // @Success 201 {object} types.CreateReviewResponse
-// @Failure 400 {string} string "Bad Request"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
 // @Router /admin/reviews [post]
-func AddReview(w http.ResponseWriter, r *http.Request) {
-	var review struct {
-		EmployeeID        int    `json:"employee_id"`        // Employee being reviewed
-		PerformanceReview string `json:"performance_review"` // Review text
-		ReviewerIDs       []int  `json:"reviewer_ids"`       // List of reviewers
-	}
-	err := json.NewDecoder(r.Body).Decode(&review)
-	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+func (h *AdminHandler) AddReview(w http.ResponseWriter, r *http.Request) {
+	var review reviewPayload
+	if !decodePayload(w, r, &review) {
 		return
 	}
 
-	tx, err := db.Conn.Begin()
-	if err != nil {
-		http.Error(w, "Error starting transaction", http.StatusInternalServerError)
+	h.editor.create(w, r, review)
+}
+
+// UpdateReview godoc
+// @Summary Update a review
+// @Description Replaces the performance review text and reviewers
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param review body object true "Review info"
+// @Param If-Match header string false "ETag of the version last read"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/reviews/{id}/comments [put]
+func (h *AdminHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
 		return
 	}
 
-	// Insert the review into the database
-	var reviewID int
-	err = tx.QueryRow(
-		"INSERT INTO reviews (employee_id, performance_review, comments) VALUES ($1, $2, $3) RETURNING id",
-		review.EmployeeID, review.PerformanceReview, pq.Array([]string{}),
-	).Scan(&reviewID)
-	if err != nil {
-		_ = tx.Rollback()
-		http.Error(w, "Error adding review", http.StatusInternalServerError)
+	var payload reviewUpdatePayload
+	if !decodePayload(w, r, &payload) {
 		return
 	}
 
-	// Add reviewers to the review_reviewers table concurrently
-	errChan := make(chan error, len(review.ReviewerIDs)) // Buffered channel for errors
-	var wg sync.WaitGroup
+	h.editor.update(w, r, reviewID, payload)
+}
 
-	for _, reviewerID := range review.ReviewerIDs {
-		wg.Go(func() {
-			_, err := tx.Exec(
-				"INSERT INTO review_reviewers (review_id, reviewer_id) VALUES ($1, $2)",
-				reviewID, reviewerID,
-			)
-			errChan <- err
-		})
+// PatchReview godoc
+// @Summary Patch a review
+// @Description Changes only the fields of a review given in a JSON Merge Patch (RFC 7396), e.g. {"performance_review": "..."} keeps the reviewers as they are
+// @Tags Admin
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param review body object true "Fields to change"
+// @Param If-Match header string false "ETag of the version last read"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/reviews/{id} [patch]
+func (h *AdminHandler) PatchReview(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
 	}
 
-	// Wait for all goroutines to finish
-	wg.Wait()
-	close(errChan)
+	h.editor.patch(w, r, reviewID)
+}
 
-	// Check for errors from the goroutines
-	for err := range errChan {
-		if err != nil {
-			_ = tx.Rollback()
-			http.Error(w, "Error adding reviewers", http.StatusInternalServerError)
-			return
-		}
+// AddReviewer godoc
+// @Summary Add a reviewer to a review
+// @Description Assigns one more reviewer to a review that can still be edited, keeping the others
+// @Tags Admin
+// @Param id path int true "Review ID"
+// @Param reviewer_id path int true "Employee ID of the reviewer"
+// @Param If-Match header string false "ETag of the version last read"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/reviews/{id}/reviewers/{reviewer_id} [post]
+func (h *AdminHandler) AddReviewer(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
 	}
-
-	// Commit the transaction
-	err = tx.Commit()
-	if err != nil {
-		http.Error(w, "Error committing transaction", http.StatusInternalServerError)
+	reviewerID, ok := pathParamID(w, r, "reviewer_id", "reviewer")
+	if !ok {
 		return
 	}
 
-	// Respond with the review ID
-	w.WriteHeader(http.StatusCreated)
-	err = json.NewEncoder(w).Encode(types.CreateReviewResponse{
-		ReviewID: reviewID,
-	})
-	if err != nil {
-		http.Error(w, "Error encoding response for add review", http.StatusInternalServerError)
-	}
+	h.editor.addReviewer(w, r, reviewID, reviewerID)
 }
 
-// UpdateReview godoc
-// @Summary Update a review
-// @Description Updates the performance review text and reviewers
+// RemoveReviewer godoc
+// @Summary Remove a reviewer from a review
+// @Description Unassigns one reviewer from a review that can still be edited, keeping the others
 // @Tags Admin
-// @Accept json
-// @Produce json
 // @Param id path int true "Review ID"
-// @Param review body object true "Review info"
+// @Param reviewer_id path int true "Employee ID of the reviewer"
+// @Param If-Match header string false "ETag of the version last read"
 // @Success 204 {string} string "No Content"
-// @Failure 400 {string} string "Bad Request"
-// @Failure 404 {string} string "Not Found"
-// @Failure 500 {string} string "Internal Server Error"
-// @Router /admin/reviews/{id}/comments [put]
-func UpdateReview(w http.ResponseWriter, r *http.Request) {
-	reviewID := router.URLParam(r, "id")
-
-	var payload struct {
-		PerformanceReview string `json:"performance_review"` // Updated review text
-		ReviewerIDs       []int  `json:"reviewer_ids"`       // List of new reviewers
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/reviews/{id}/reviewers/{reviewer_id} [delete]
+func (h *AdminHandler) RemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
 	}
-	err := json.NewDecoder(r.Body).Decode(&payload)
-	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	reviewerID, ok := pathParamID(w, r, "reviewer_id", "reviewer")
+	if !ok {
 		return
 	}
 
-	tx, err := db.Conn.Begin()
-	if err != nil {
-		http.Error(w, "Error starting transaction", http.StatusInternalServerError)
+	h.editor.removeReviewer(w, r, reviewID, reviewerID)
+}
+
+// GetReview godoc
+// @Summary Get a review
+// @Description Fetches one review along with reviewers and submitted feedback. The ETag header carries the review's version for If-Match on later writes; send it as If-None-Match to get 304 while the review is unchanged.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param If-None-Match header string false "ETag of the version already held"
+// @Success 200 {object} types.ReviewResponse
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/reviews/{id} [get]
+func (h *AdminHandler) GetReview(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
 		return
 	}
 
-	// Update the performance review
-	_, err = tx.Exec(
-		"UPDATE reviews SET performance_review = $1 WHERE id = $2",
-		payload.PerformanceReview, reviewID,
-	)
-	if err != nil {
-		_ = tx.Rollback()
-		http.Error(w, "Error updating review", http.StatusInternalServerError)
+	review, err := h.reviews.Get(r.Context(), reviewID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewNotFound, "Review not found")
 		return
 	}
-
-	// Clear existing reviewers
-	_, err = tx.Exec("DELETE FROM review_reviewers WHERE review_id = $1", reviewID)
 	if err != nil {
-		_ = tx.Rollback()
-		http.Error(w, "Error clearing existing reviewers", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review")
 		return
 	}
-
-	// Add new reviewers concurrently
-	errChan := make(chan error, len(payload.ReviewerIDs)) // Buffered channel for errors
-	var wg sync.WaitGroup
-
-	for _, reviewerID := range payload.ReviewerIDs {
-		wg.Go(func() {
-			_, err := tx.Exec(
-				"INSERT INTO review_reviewers (review_id, reviewer_id) VALUES ($1, $2)",
-				reviewID, reviewerID,
-			)
-			errChan <- err
-		})
-	}
-
-	// Wait for all goroutines to finish
-	wg.Wait()
-	close(errChan)
-
-	// Check for errors from the goroutines
-	for err := range errChan {
-		if err != nil {
-			_ = tx.Rollback()
-			http.Error(w, "Error adding reviewers", http.StatusInternalServerError)
-			return
-		}
+	if notModified(w, r, review.Version) {
+		return
 	}
-
-	// Commit the transaction
-	err = tx.Commit()
+	comments, err := loadComments(r.Context(), h.feedback, []store.Review{review})
 	if err != nil {
-		http.Error(w, "Error committing transaction", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching feedback")
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reviewResponses([]store.Review{review}, comments)[0]); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
 }
 
 // GetReviews godoc
 // @Summary Get all reviews
-// @Description Fetches all reviews along with reviewers
+// @Description Fetches one page of reviews along with reviewers, optionally filtered and sorted. Pass next_cursor from the response as cursor to fetch the next page.
 // @Tags Admin
 // @Produce json
-// @Success 200 {array} types.ReviewResponse
-// @Failure 500 {string} string "Internal Server Error"
+// @Param cycle_id query int false "Only reviews in this cycle"
+// @Param status query string false "Only reviews in this status"
+// @Param employee_id query int false "Only reviews of this employee"
+// @Param reviewer_id query int false "Only reviews this employee is a reviewer of"
+// @Param created_after query string false "Only reviews created after this RFC 3339 time"
+// @Param created_before query string false "Only reviews created before this RFC 3339 time"
+// @Param has_feedback query bool false "Only reviews with (true) or without (false) submitted feedback"
+// @Param sort query string false "Comma-separated fields among id, cycle_id, employee_id, email, status and created_at, each prefixed with - for descending order, e.g. created_at,-email"
+// @Param limit query int false "Reviews per page, 1 to 200, default 50"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} types.ReviewListResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
 // @Router /admin/reviews [get]
-func GetReviews(w http.ResponseWriter, r *http.Request) {
-	rows, err := db.Conn.Query(`
-		SELECT r.id, r.employee_id, e.email AS employee_email, r.performance_review, r.comments, r.created_at,
-		       ARRAY_AGG(rr.reviewer_id) AS reviewer_ids
-		FROM reviews r
-		JOIN employees e ON r.employee_id = e.id
-		LEFT JOIN review_reviewers rr ON r.id = rr.review_id
-		GROUP BY r.id, e.email
-	`)
+func (h *AdminHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseReviewFilter(r)
 	if err != nil {
-		http.Error(w, "Error fetching reviews", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	page, err := parsePage(r, store.ReviewSortFields, store.Review{})
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			log.Printf("Error closing rows: %v", err)
-		}
-	}()
 
-	var reviews []types.ReviewResponse
-	for rows.Next() {
-		var id, employeeID int
-		var employeeEmail, performanceReview string
-		var comments []string
-		var reviewerIDs []int
-		var createdAt string
-		err := rows.Scan(&id, &employeeID, &employeeEmail, &performanceReview, pq.Array(&comments), &createdAt, pq.Array(&reviewerIDs))
-		if err != nil {
-			http.Error(w, "Error scanning review data", http.StatusInternalServerError)
-			return
-		}
-		reviews = append(reviews, types.ReviewResponse{
-			ID:                id,
-			EmployeeID:        employeeID,
-			EmployeeEmail:     employeeEmail,
-			PerformanceReview: performanceReview,
-			Comments:          comments,
-			ReviewerIDs:       reviewerIDs,
-			CreatedAt:         createdAt,
-		})
+	stored, err := h.reviews.List(r.Context(), filter, page)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reviews")
+		return
+	}
+	stored, next := nextCursor(stored, page)
+	comments, err := loadComments(r.Context(), h.feedback, stored)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching feedback")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(reviews); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(reviewList(stored, comments, next)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+
+}
+
+// reviewList builds a page of reviews for admins and managers
+func reviewList(stored []store.Review, comments map[int][]types.FeedbackResponse, next string) types.ReviewListResponse {
+	reviews := types.ReviewListResponse{Items: reviewResponses(stored, comments), NextCursor: next}
+	if reviews.Items == nil {
+		reviews.Items = []types.ReviewResponse{}
 	}
+	return reviews
+}
 
+// reviewResponses builds the admin view of reviews with their submitted feedback
+func reviewResponses(stored []store.Review, comments map[int][]types.FeedbackResponse) []types.ReviewResponse {
+	var reviews []types.ReviewResponse
+	for _, review := range stored {
+		reviews = append(reviews, types.ReviewResponse{
+			ID:                review.ID,
+			CycleID:           review.CycleID,
+			TemplateID:        review.TemplateID,
+			EmployeeID:        review.EmployeeID,
+			EmployeeEmail:     review.EmployeeEmail,
+			PerformanceReview: review.PerformanceReview,
+			Status:            review.Status,
+			Comments:          comments[review.ID],
+			Ratings:           aggregateRatings(comments[review.ID]),
+			ReviewerIDs:       review.ReviewerIDs,
+			Version:           review.Version,
+			CreatedAt:         review.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return reviews
 }
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/passwords"
+	"go-api/store"
+	"go-api/store/memory"
+	"go-api/types"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// newTestAdmin serves the admin routes under test from an in-memory backend
+// holding employees 1 and 2, review 1 of employee 1 in open cycle 1 and
+// closed cycle 2
+func newTestAdmin(t *testing.T) http.Handler {
+	t.Helper()
+	ctx := context.Background()
+	stores := memory.New()
+
+	for _, email := range []string{"employee1@example.com", "employee2@example.com"} {
+		if _, err := stores.Employees.Create(ctx, store.Employee{Email: email, Position: "Developer"}, "not-a-real-hash"); err != nil {
+			t.Fatalf("creating employee: %v", err)
+		}
+	}
+	now := time.Now()
+	cycle, err := stores.Cycles.Create(ctx, store.Cycle{
+		Name:               "Current",
+		StartsAt:           now.Add(-time.Hour),
+		SelfReviewDeadline: now.Add(24 * time.Hour),
+		PeerReviewDeadline: now.Add(48 * time.Hour),
+		ClosesAt:           now.Add(72 * time.Hour),
+		Status:             store.CycleOpen,
+	})
+	if err != nil {
+		t.Fatalf("creating cycle: %v", err)
+	}
+	closed := cycle
+	closed.Status = store.CycleClosed
+	if _, err := stores.Cycles.Create(ctx, closed); err != nil {
+		t.Fatalf("creating cycle: %v", err)
+	}
+	if _, err := stores.Reviews.Create(ctx, store.Review{CycleID: cycle.ID, EmployeeID: 1, ReviewerIDs: []int{2}}); err != nil {
+		t.Fatalf("creating review: %v", err)
+	}
+
+	h := NewAdminHandler(stores, &passwords.Policy{MinLength: passwords.DefaultMinLength}, nil)
+	r := router.NewRouter()
+	r.Post("/admin/reviews", h.AddReview)
+	r.Put("/admin/employees/{id}", h.UpdateEmployee)
+	r.Delete("/admin/employees/{id}", h.RemoveEmployee)
+	r.Put("/admin/reviews/{id}/comments", h.UpdateReview)
+	return r
+}
+
+// adminTestCase is a request to one of the routes of newTestAdmin and the
+// status and error code it must get
+type adminTestCase struct {
+	name       string
+	path       string
+	wantStatus int
+	wantCode   string
+}
+
+func runAdminTests(t *testing.T, method, body string, tests []adminTestCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestAdmin(t)
+			req := httptest.NewRequest(method, tt.path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			if code := errorCode(t, rec); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// errorCode returns the code of the error response recorded by rec
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var problem types.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("decoding error response: %v", err)
+	}
+	return problem.Code
+}
+
+func TestUpdateEmployee(t *testing.T) {
+	runAdminTests(t, http.MethodPut, `{"email": "renamed@example.com", "position": "Lead"}`, []adminTestCase{
+		{name: "existing employee", path: "/admin/employees/1", wantStatus: http.StatusNoContent},
+		{name: "missing employee", path: "/admin/employees/999", wantStatus: http.StatusNotFound, wantCode: CodeEmployeeNotFound},
+		{name: "non-integer ID", path: "/admin/employees/abc", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+		{name: "zero ID", path: "/admin/employees/0", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+		{name: "negative ID", path: "/admin/employees/-1", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+	})
+}
+
+func TestRemoveEmployee(t *testing.T) {
+	runAdminTests(t, http.MethodDelete, "", []adminTestCase{
+		{name: "existing employee", path: "/admin/employees/2", wantStatus: http.StatusNoContent},
+		{name: "missing employee", path: "/admin/employees/999", wantStatus: http.StatusNotFound, wantCode: CodeEmployeeNotFound},
+		{name: "non-integer ID", path: "/admin/employees/abc", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+		{name: "zero ID", path: "/admin/employees/0", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+		{name: "negative ID", path: "/admin/employees/-1", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+	})
+}
+
+func TestUpdateReview(t *testing.T) {
+	runAdminTests(t, http.MethodPut, `{"performance_review": "Solid quarter", "reviewer_ids": [2]}`, []adminTestCase{
+		{name: "existing review", path: "/admin/reviews/1/comments", wantStatus: http.StatusNoContent},
+		{name: "missing review", path: "/admin/reviews/999/comments", wantStatus: http.StatusNotFound, wantCode: CodeReviewNotFound},
+		{name: "non-integer ID", path: "/admin/reviews/abc/comments", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+		{name: "zero ID", path: "/admin/reviews/0/comments", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+		{name: "negative ID", path: "/admin/reviews/-1/comments", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest},
+	})
+}
+
+func TestAddReview(t *testing.T) {
+	tests := []struct {
+		name string
+		step testStep
+	}{
+		{name: "valid", step: testStep{body: `{"cycle_id": 1, "employee_id": 2, "reviewer_ids": [1]}`, wantStatus: http.StatusCreated}},
+		{name: "missing cycle", step: testStep{body: `{"cycle_id": 999, "employee_id": 2}`, wantStatus: http.StatusBadRequest, wantCode: CodeCycleNotFound}},
+		{name: "closed cycle", step: testStep{body: `{"cycle_id": 2, "employee_id": 2}`, wantStatus: http.StatusConflict, wantCode: CodeCycleClosed}},
+		{name: "missing employee", step: testStep{body: `{"cycle_id": 1, "employee_id": 999}`, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed}},
+		{name: "employee reviewing themselves", step: testStep{body: `{"cycle_id": 1, "employee_id": 2, "reviewer_ids": [2]}`, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed}},
+		{name: "no cycle", step: testStep{body: `{"employee_id": 2}`, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed}},
+		{name: "malformed body", step: testStep{body: `{"cycle_id": "one"}`, wantStatus: http.StatusBadRequest, wantCode: CodeInvalidRequest}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.step.method = http.MethodPost
+			tt.step.path = "/admin/reviews"
+			runSteps(t, newTestAdmin(t), []testStep{tt.step})
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go-api/store"
+	"go-api/types"
+	"go-api/validate"
+)
+
+// APIKeyPrefix starts every personal API key so they can be told apart from JWTs
+const APIKeyPrefix = "rk_"
+
+// apiKeyPrefixLength is how much of a key is stored in the clear to identify it
+const apiKeyPrefixLength = len(APIKeyPrefix) + 6
+
+// APIKeyRequest describes a personal API key to create
+type APIKeyRequest struct {
+	Name        string   `json:"name" validate:"required,max=100"`
+	Permissions []string `json:"permissions" validate:"required,unique"`
+	// ExpiresAt is optional; keys without it work until they are revoked
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Creates a personal API key for scripts, used as "Authorization: Bearer rk_...". It grants the given permissions, which must be among your own, and never more than your role does when it is used.
+// @Description The key is only shown in this response.
+// @Tags Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body handlers.APIKeyRequest true "Name, permissions and optional expiry"
+// @Success 201 {object} types.APIKeyResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /me/api-keys [post]
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+	var payload APIKeyRequest
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+	var notHeld []string
+	for _, permission := range payload.Permissions {
+		if !claims.HasPermission(permission) {
+			notHeld = append(notHeld, permission)
+		}
+	}
+	if len(notHeld) > 0 {
+		writeValidationErrors(w, r, validate.Errors{"permissions": "must be among your own, you do not have " + strings.Join(notHeld, ", ")})
+		return
+	}
+	key := store.APIKey{
+		UserID:      claims.ID,
+		Name:        strings.TrimSpace(payload.Name),
+		Permissions: slices.Compact(slices.Sorted(slices.Values(payload.Permissions))),
+	}
+	if payload.ExpiresAt != nil {
+		if !payload.ExpiresAt.After(time.Now()) {
+			writeValidationErrors(w, r, validate.Errors{"expires_at": "must be in the future"})
+			return
+		}
+		key.ExpiresAt = *payload.ExpiresAt
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error creating API key")
+		return
+	}
+	secret = APIKeyPrefix + secret
+	key.Prefix = secret[:apiKeyPrefixLength]
+	key.KeyHash = HashAPIKey(secret)
+
+	key, err = h.apiKeys.Create(r.Context(), key)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating API key of user %d: %v", claims.ID, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error creating API key")
+		return
+	}
+
+	response := apiKeyResponse(key)
+	response.Key = secret
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding API key: %v", err)
+	}
+}
+
+// GetAPIKeys godoc
+// @Summary Get your API keys
+// @Description Lists your personal API keys with when each was last used. The keys themselves are not shown, only their prefix.
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} types.APIKeyResponse
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /me/api-keys [get]
+func (h *AuthHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	stored, err := h.apiKeys.List(r.Context(), claims.ID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching API keys")
+		return
+	}
+	keys := []types.APIKeyResponse{}
+	for _, key := range stored {
+		keys = append(keys, apiKeyResponse(key))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Deletes one of your personal API keys; requests using it are refused from then on
+// @Tags Authentication
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /me/api-keys/{id} [delete]
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+	keyID, ok := pathID(w, r, "API key")
+	if !ok {
+		return
+	}
+
+	err := h.apiKeys.Delete(r.Context(), claims.ID, keyID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeAPIKeyNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error revoking API key")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by
+func HashAPIKey(key string) string {
+	return hashToken(key)
+}
+
+// apiKeyResponse converts a stored key, leaving out the key itself
+func apiKeyResponse(key store.APIKey) types.APIKeyResponse {
+	response := types.APIKeyResponse{
+		ID:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Permissions: key.Permissions,
+		CreatedAt:   key.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if response.Permissions == nil {
+		response.Permissions = []string{}
+	}
+	if !key.ExpiresAt.IsZero() {
+		response.ExpiresAt = key.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if !key.LastUsedAt.IsZero() {
+		response.LastUsedAt = key.LastUsedAt.UTC().Format(time.RFC3339)
+	}
+	return response
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name string
+		step testStep
+	}{
+		{name: "held permission", step: testStep{body: `{"name": "sync", "permissions": ["employees:read"]}`, wantStatus: http.StatusCreated}},
+		{name: "permission not held", step: testStep{body: `{"name": "sync", "permissions": ["employees:read", "employees:manage"]}`, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed}},
+		{name: "expired", step: testStep{body: `{"name": "sync", "permissions": ["employees:read"], "expires_at": "2000-01-01T00:00:00Z"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.step.method = http.MethodPost
+			tt.step.path = "/me/api-keys"
+			runSteps(t, newTestAuth(t), []testStep{tt.step})
+		})
+	}
+}
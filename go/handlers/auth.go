@@ -1,100 +1,241 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/dgrijalva/jwt-go"
-	"go-api/db"
+	"go-api/keys"
+	"go-api/store"
 	"go-api/types"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// JwtKey is the secret key used to sign tokens
-var JwtKey = []byte("your_secret_key")
-
 type Credentials struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,max=254"`
+	Password string `json:"password" validate:"required"`
 }
 
+// Built-in roles; the permissions of every role are stored with the roles
+const (
+	RoleAdmin    = "admin"
+	RoleEmployee = "employee"
+	// RoleManager is given at login to employees who have direct reports
+	RoleManager = "manager"
+)
+
 type Claims struct {
 	ID    int    `json:"id"`
 	Email string `json:"email"`
 	Role  string `json:"role"`
+	// Permissions are those of Role when the token was issued
+	Permissions []string `json:"permissions"`
+	// SessionID is the refresh token family the token was issued with; the
+	// token ID (jti) is StandardClaims.Id
+	SessionID string `json:"sid"`
+	// APIKeyID is set instead of SessionID for requests made with a personal API key
+	APIKeyID int `json:"-"`
 	jwt.StandardClaims
 }
 
+// HasPermission reports whether the token grants the permission
+func (c *Claims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+type contextKey string
+
+const claimsKey contextKey = "claims"
+
+// AuthHandler serves the authentication routes
+type AuthHandler struct {
+	users     store.UserStore
+	employees store.EmployeeStore
+	roles     store.RoleStore
+	tokens    store.TokenStore
+	mfa       store.MFAStore
+	throttles store.LoginThrottleStore
+	apiKeys   store.APIKeyStore
+	keys      *keys.Keyring
+}
+
+// NewAuthHandler creates an AuthHandler using the given stores, signing tokens with keyring
+func NewAuthHandler(stores store.Stores, keyring *keys.Keyring) *AuthHandler {
+	return &AuthHandler{
+		users:     stores.Users,
+		employees: stores.Employees,
+		roles:     stores.Roles,
+		tokens:    stores.Tokens,
+		mfa:       stores.MFA,
+		throttles: stores.Throttles,
+		apiKeys:   stores.APIKeys,
+		keys:      keyring,
+	}
+}
+
 // Login godoc
 // @Summary Login to generate a JWT token
-// @Description Logs in a user with email and password, and returns a JWT token.
+// @Description Logs in a user with email and password, and returns a short-lived JWT access token with a refresh token.
+// @Description Users with two-factor authentication get an mfa_token to finish logging in at /login/mfa instead.
+// @Description Repeated failures slow down and then temporarily lock the account and client.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param credentials body handlers.Credentials true "Email and Password"
-// @Success 200 {object} types.TokenResponse
-// @Failure 401 {string} string "Unauthorized"
+// @Success 200 {object} types.TokenResponse "Tokens, or a types.MFAChallengeResponse when a second factor is needed"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 429 {object} types.ErrorResponse "Too Many Requests"
 // @Router /login [post]
-func Login(w http.ResponseWriter, r *http.Request) {
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var creds Credentials
-	err := json.NewDecoder(r.Body).Decode(&creds)
-	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if !decodePayload(w, r, &creds) {
 		return
 	}
 
-	var userID int
-	var hashedPassword, role string
-	err = db.Conn.QueryRow("SELECT id, password, role FROM users WHERE email = $1", creds.Email).Scan(&userID, &hashedPassword, &role)
+	if !h.checkThrottle(w, r, creds.Email) {
+		return
+	}
+
+	user, err := h.users.GetByEmail(r.Context(), creds.Email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Internal Server Error")
+		return
+	}
+
+	// Unknown emails and accounts without a password still pay for a bcrypt
+	// comparison so response times do not reveal which emails have accounts
+	known := err == nil && user.PasswordHash != ""
+	hash := []byte(user.PasswordHash)
+	if !known {
+		hash = dummyPasswordHash()
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(creds.Password)) != nil || !known {
+		h.recordLoginFailure(r.Context(), r, creds.Email)
+		WriteError(w, r, http.StatusUnauthorized, CodeInvalidCredentials, "Invalid email or password")
+		return
+	}
+
+	h.resetLoginFailures(r.Context(), user.Email)
+	h.completeLogin(w, r, user)
+}
+
+// startSession issues the first token pair of a new login session for user.
+// recoveryCodes are passed on to the response.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, user store.User, recoveryCodes ...string) {
+	refreshToken, record, err := newTokenPair()
 	if err != nil {
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return
+	}
+	// Every login starts a new family of refresh tokens
+	record.FamilyID, err = randomToken(16)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return
+	}
+	record.UserID = user.ID
+	if err := h.tokens.Create(r.Context(), record); err != nil {
+		log.Printf("Error storing refresh token: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(creds.Password))
+	h.writeTokens(w, r, user, record, refreshToken, recoveryCodes)
+}
+
+// writeTokens signs the access token described by record and responds with it
+// and the refresh token
+func (h *AuthHandler) writeTokens(w http.ResponseWriter, r *http.Request, user store.User, record store.RefreshToken, refreshToken string, recoveryCodes []string) {
+	role, err := h.effectiveRole(r.Context(), user)
 	if err != nil {
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return
+	}
+	permissions, err := h.roles.Permissions(r.Context(), role)
+	if err != nil {
+		log.Printf("Error loading permissions of role %s: %v", role, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
 		return
 	}
 
-	expirationTime := time.Now().Add(24 * time.Hour)
 	claims := &Claims{
-		ID:    userID,
-		Email: creds.Email,
-		Role:  role,
+		ID:          user.ID,
+		Email:       user.Email,
+		Role:        role,
+		Permissions: permissions,
+		SessionID:   record.FamilyID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Id:        record.AccessJTI,
+			ExpiresAt: record.AccessExpiresAt.Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(JwtKey)
+	tokenString, err := h.keys.Sign(claims)
 	if err != nil {
-		http.Error(w, "Could not create token", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	response := types.TokenResponse{Token: tokenString}
+	response := types.TokenResponse{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(AccessTokenTTL.Seconds()),
+
+		RecoveryCodes: recoveryCodes,
+	}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		fmt.Printf("Error encoding response JSON: %v\n", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
 		return
 	}
 }
 
+// effectiveRole returns the role to put in the user's token
+func (h *AuthHandler) effectiveRole(ctx context.Context, user store.User) (string, error) {
+	return EffectiveRole(ctx, h.employees, user)
+}
+
+// EffectiveRole returns the role the user acts with, promoting employees with
+// direct reports to managers
+func EffectiveRole(ctx context.Context, employees store.EmployeeStore, user store.User) (string, error) {
+	if user.Role != RoleEmployee {
+		return user.Role, nil
+	}
+
+	employee, err := employees.GetByEmail(ctx, user.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		return user.Role, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	reports, err := employees.Reports(ctx, employee.ID, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(reports) > 0 {
+		return RoleManager, nil
+	}
+	return user.Role, nil
+}
+
 // hashPassword generates a bcrypt hash for the given password
 func hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
 }
 
-// ExtractClaims extracts the claims from the Authorization header in the HTTP request
-func ExtractClaims(r *http.Request) (*Claims, error) {
+// ExtractClaims extracts the claims from the Authorization header in the HTTP
+// request, verifying the token with keyring
+func ExtractClaims(r *http.Request, keyring *keys.Keyring) (*Claims, error) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
 		return nil, errors.New("authorization header missing")
@@ -110,12 +251,21 @@ func ExtractClaims(r *http.Request) (*Claims, error) {
 
 	// Parse the token and validate it
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return JwtKey, nil
-	})
+	token, err := keyring.Parse(tokenString, claims)
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid or expired token")
 	}
 
 	return claims, nil
 }
+
+// WithClaims returns a copy of ctx carrying the authenticated user's claims
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns the claims stored by the auth middleware
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok && claims != nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-api/keys"
+	"go-api/store"
+	"go-api/store/memory"
+	"go-api/types"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// newTestAuth serves /login and /me/api-keys from an in-memory backend holding
+// the seeded accounts, with the admin signed in holding only employees:read
+// for the latter
+func newTestAuth(t *testing.T) http.Handler {
+	t.Helper()
+	stores := memory.New()
+	if err := store.Seed(context.Background(), stores); err != nil {
+		t.Fatalf("seeding: %v", err)
+	}
+	keyring, err := keys.New(keys.NewHMACKey("test", []byte("a-test-secret-of-at-least-32-bytes")))
+	if err != nil {
+		t.Fatalf("creating keyring: %v", err)
+	}
+
+	h := NewAuthHandler(stores, keyring)
+	r := router.NewRouter()
+	r.Post("/login", h.Login)
+	r.Post("/me/api-keys", func(w http.ResponseWriter, r *http.Request) {
+		claims := &Claims{ID: 1, Email: "admin@example.com", Permissions: []string{store.PermEmployeesRead}}
+		h.CreateAPIKey(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+	return r
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "admin", body: `{"email": "admin@example.com", "password": "admin"}`, wantStatus: http.StatusOK},
+		{name: "employee", body: `{"email": "employee1@example.com", "password": "employee"}`, wantStatus: http.StatusOK},
+		{name: "wrong password", body: `{"email": "admin@example.com", "password": "wrong"}`, wantStatus: http.StatusUnauthorized, wantCode: CodeInvalidCredentials},
+		{name: "unknown email", body: `{"email": "nobody@example.com", "password": "admin"}`, wantStatus: http.StatusUnauthorized, wantCode: CodeInvalidCredentials},
+		{name: "no password", body: `{"email": "admin@example.com"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			newTestAuth(t).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, rec); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			var tokens types.TokenResponse
+			if err := json.NewDecoder(rec.Body).Decode(&tokens); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if tokens.Token == "" || tokens.RefreshToken == "" {
+				t.Errorf("response has no tokens: %+v", tokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-api/store"
+	"go-api/types"
+	"go-api/validate"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// /cycles handlers
+
+// cyclePayload is the request body for creating and updating review cycles
+type cyclePayload struct {
+	Name               string    `json:"name" validate:"required,max=100"`
+	StartsAt           time.Time `json:"starts_at" validate:"required"`
+	SelfReviewDeadline time.Time `json:"self_review_deadline" validate:"required"`
+	PeerReviewDeadline time.Time `json:"peer_review_deadline" validate:"required"`
+	ClosesAt           time.Time `json:"closes_at" validate:"required"`
+	Status             string    `json:"status" validate:"oneof=draft open closed"`
+}
+
+// toCycle checks that the deadlines are in order and converts the payload to a store.Cycle
+func (p cyclePayload) toCycle() (store.Cycle, validate.Errors) {
+	errs := validate.Errors{}
+	if p.SelfReviewDeadline.Before(p.StartsAt) {
+		errs.Add("self_review_deadline", "must not be before starts_at")
+	}
+	if p.PeerReviewDeadline.Before(p.SelfReviewDeadline) {
+		errs.Add("peer_review_deadline", "must not be before self_review_deadline")
+	}
+	if p.ClosesAt.Before(p.PeerReviewDeadline) {
+		errs.Add("closes_at", "must not be before peer_review_deadline")
+	}
+	if len(errs) > 0 {
+		return store.Cycle{}, errs
+	}
+
+	status := p.Status
+	if status == "" {
+		status = store.CycleDraft
+	}
+
+	return store.Cycle{
+		Name:               p.Name,
+		StartsAt:           p.StartsAt,
+		SelfReviewDeadline: p.SelfReviewDeadline,
+		PeerReviewDeadline: p.PeerReviewDeadline,
+		ClosesAt:           p.ClosesAt,
+		Status:             status,
+	}, nil
+}
+
+// AddCycle godoc
+// @Summary Add a review cycle
+// @Description Creates a review cycle with its deadlines
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param cycle body object true "Cycle info"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 201 {object} types.CycleResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/cycles [post]
+func (h *AdminHandler) AddCycle(w http.ResponseWriter, r *http.Request) {
+	var payload cyclePayload
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	cycle, errs := payload.toCycle()
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	cycle, err := h.cycles.Create(r.Context(), cycle)
+	if err != nil {
+		log.Printf("Error adding review cycle: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error adding review cycle")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(cycleResponse(cycle)); err != nil {
+		log.Printf("Error encoding review cycle: %v", err)
+	}
+}
+
+// GetCycles godoc
+// @Summary Get all review cycles
+// @Description Retrieves every review cycle, most recent first
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} types.CycleResponse
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/cycles [get]
+func (h *AdminHandler) GetCycles(w http.ResponseWriter, r *http.Request) {
+	stored, err := h.cycles.List(r.Context())
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review cycles")
+		return
+	}
+
+	cycles := []types.CycleResponse{}
+	for _, cycle := range stored {
+		cycles = append(cycles, cycleResponse(cycle))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cycles); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// GetCycle godoc
+// @Summary Get a review cycle
+// @Description Retrieves a single review cycle
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Cycle ID"
+// @Success 200 {object} types.CycleResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/cycles/{id} [get]
+func (h *AdminHandler) GetCycle(w http.ResponseWriter, r *http.Request) {
+	cycleID, ok := pathID(w, r, "cycle")
+	if !ok {
+		return
+	}
+
+	cycle, err := h.cycles.Get(r.Context(), cycleID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeCycleNotFound, "Review cycle not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review cycle")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cycleResponse(cycle)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// UpdateCycle godoc
+// @Summary Update a review cycle
+// @Description Replaces a review cycle's name, deadlines and status
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Cycle ID"
+// @Param cycle body object true "Cycle info"
+// @Success 200 {object} types.CycleResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/cycles/{id} [put]
+func (h *AdminHandler) UpdateCycle(w http.ResponseWriter, r *http.Request) {
+	cycleID, ok := pathID(w, r, "cycle")
+	if !ok {
+		return
+	}
+
+	var payload cyclePayload
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	cycle, errs := payload.toCycle()
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	cycle.ID = cycleID
+
+	cycle, err := h.cycles.Update(r.Context(), cycle)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeCycleNotFound, "Review cycle not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating review cycle: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error updating review cycle")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cycleResponse(cycle)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// RemoveCycle godoc
+// @Summary Remove a review cycle
+// @Description Removes a review cycle that has no reviews
+// @Tags Admin
+// @Param id path int true "Cycle ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/cycles/{id} [delete]
+func (h *AdminHandler) RemoveCycle(w http.ResponseWriter, r *http.Request) {
+	cycleID, ok := pathID(w, r, "cycle")
+	if !ok {
+		return
+	}
+
+	err := h.cycles.Delete(r.Context(), cycleID)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		WriteError(w, r, http.StatusNotFound, CodeCycleNotFound, "Review cycle not found")
+		return
+	case errors.Is(err, store.ErrCycleInUse):
+		WriteError(w, r, http.StatusConflict, CodeCycleInUse, "Review cycle still has reviews")
+		return
+	case err != nil:
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error removing review cycle")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseReviewFilter reads the review list filters from the query string
+func parseReviewFilter(r *http.Request) (store.ReviewFilter, error) {
+	var filter store.ReviewFilter
+	var err error
+	if filter.CycleID, err = queryID(r, "cycle_id"); err != nil {
+		return filter, err
+	}
+	if status := router.URLQuery(r, "status"); status != "" {
+		if !store.IsReviewStatus(status) {
+			return filter, errors.New("invalid status")
+		}
+		filter.Status = status
+	}
+	if filter.EmployeeID, err = queryID(r, "employee_id"); err != nil {
+		return filter, err
+	}
+	if filter.ReviewerID, err = queryID(r, "reviewer_id"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedAfter, err = queryTime(r, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = queryTime(r, "created_before"); err != nil {
+		return filter, err
+	}
+	if value := router.URLQuery(r, "has_feedback"); value != "" {
+		hasFeedback, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("invalid has_feedback, must be true or false")
+		}
+		filter.HasFeedback = &hasFeedback
+	}
+	return filter, nil
+}
+
+func cycleResponse(cycle store.Cycle) types.CycleResponse {
+	return types.CycleResponse{
+		ID:                 cycle.ID,
+		Name:               cycle.Name,
+		StartsAt:           cycle.StartsAt.UTC().Format(time.RFC3339),
+		SelfReviewDeadline: cycle.SelfReviewDeadline.UTC().Format(time.RFC3339),
+		PeerReviewDeadline: cycle.PeerReviewDeadline.UTC().Format(time.RFC3339),
+		ClosesAt:           cycle.ClosesAt.UTC().Format(time.RFC3339),
+		Status:             cycle.Status,
+		CreatedAt:          cycle.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -2,81 +2,96 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
-	"go-api/db"
+	"go-api/store"
 	"go-api/types"
 )
 
-type contextKey string
+// EmployeeHandler serves the /employee routes
+type EmployeeHandler struct {
+	employees store.EmployeeStore
+	reviews   store.ReviewStore
+	cycles    store.CycleStore
+	feedback  store.FeedbackStore
+	templates store.TemplateStore
+}
 
-const userIDKey contextKey = "id"
+// NewEmployeeHandler creates an EmployeeHandler using the given stores
+func NewEmployeeHandler(stores store.Stores) *EmployeeHandler {
+	return &EmployeeHandler{
+		employees: stores.Employees,
+		reviews:   stores.Reviews,
+		cycles:    stores.Cycles,
+		feedback:  stores.Feedback,
+		templates: stores.Templates,
+	}
+}
 
 // ListReviews godoc
 // @Summary List assigned reviews
-// @Description Lists reviews assigned to the employee that have not been submitted yet
+// @Description Lists one page of in-progress reviews assigned to the employee, optionally filtered and sorted. Pass next_cursor from the response as cursor to fetch the next page.
 // @Tags Employee
 // @Produce json
-// @Success 200 {array} types.AssignedReviewResponse
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 500 {string} string "Internal Server Error"
+// @Param cycle_id query int false "Only reviews in this cycle"
+// @Param employee_id query int false "Only reviews of this employee"
+// @Param created_after query string false "Only reviews created after this RFC 3339 time"
+// @Param created_before query string false "Only reviews created before this RFC 3339 time"
+// @Param sort query string false "Comma-separated fields among id, cycle_id, employee_id, email, status and created_at, each prefixed with - for descending order, e.g. created_at,-email"
+// @Param limit query int false "Reviews per page, 1 to 200, default 50"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} types.AssignedReviewListResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
 // @Router /employee/reviews [get]
-func ListReviews(w http.ResponseWriter, r *http.Request) {
-	// Get id from context added from auth
-	idValue := r.Context().Value(userIDKey)
-	employeeID, ok := idValue.(string)
-	if !ok || employeeID == "" {
-		http.Error(w, "Invalid ID", http.StatusUnauthorized)
+func (h *EmployeeHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	// Resolve the employee from the claims added by auth
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	filter, err := parseReviewFilter(r)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	page, err := parsePage(r, store.ReviewSortFields, store.Review{})
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Fetch reviews assigned to the employee that have not been submitted yet
-	rows, err := db.Conn.QueryContext(r.Context(), `
-        SELECT r.id, e.email AS employee_email, r.performance_review
-        FROM reviews r
-        JOIN employees e ON r.employee_id = e.id
-        LEFT JOIN feedback f ON r.id = f.review_id AND f.submitted = TRUE
-        WHERE f.review_id IS NULL AND r.id IN (
-            SELECT review_id FROM review_reviewers WHERE reviewer_id = $1
-        )
-    `, employeeID)
+	pending, err := h.reviews.ListPending(r.Context(), employee.ID, filter, page)
 	if err != nil {
-		http.Error(w, "Error fetching reviews", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reviews")
 		return
 	}
-	defer func() {
-		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("Error closing rows: %v", closeErr)
-		}
-	}()
+	pending, next := nextCursor(pending, page)
 
 	// Build the list of reviews
-	var reviews []types.AssignedReviewResponse
-	for rows.Next() {
-		var id int
-		var employeeEmail, performanceReview string
-		if err := rows.Scan(&id, &employeeEmail, &performanceReview); err != nil {
-			http.Error(w, "Error scanning review data", http.StatusInternalServerError)
-			return
-		}
-		reviews = append(reviews, types.AssignedReviewResponse{
-			ID:                id,
-			EmployeeEmail:     employeeEmail,
-			PerformanceReview: performanceReview,
+	reviews := types.AssignedReviewListResponse{Items: []types.AssignedReviewResponse{}, NextCursor: next}
+	for _, review := range pending {
+		reviews.Items = append(reviews.Items, types.AssignedReviewResponse{
+			ID:                review.ID,
+			CycleID:           review.CycleID,
+			TemplateID:        review.TemplateID,
+			EmployeeEmail:     review.EmployeeEmail,
+			PerformanceReview: review.PerformanceReview,
+			CreatedAt:         review.CreatedAt.UTC().Format(time.RFC3339),
 		})
 	}
 
-	// Check for errors during iteration
-	if err := rows.Err(); err != nil {
-		http.Error(w, "Error iterating over review data", http.StatusInternalServerError)
-		return
-	}
-
 	// Respond with the list of reviews
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(reviews); err != nil {
-		http.Error(w, "Error encoding response for listed reviews", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error encoding response for listed reviews")
 	}
 }
 
@@ -87,24 +102,28 @@ func ListReviews(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param feedback body object true "Feedback info"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
 // @Success 201 {object} types.MessageResponse
-// @Failure 400 {string} string "Bad Request"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
 // @Router /employee/reviews/feedback [post]
-func SubmitFeedback(w http.ResponseWriter, r *http.Request) {
-	// Get id from context added from auth
-	idValue := r.Context().Value(userIDKey)
-	employeeID, ok := idValue.(string)
-	if !ok || employeeID == "" {
-		http.Error(w, "Invalid ID", http.StatusUnauthorized)
+func (h *EmployeeHandler) SubmitFeedback(w http.ResponseWriter, r *http.Request) {
+	// Resolve the employee from the claims added by auth
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
 		return
 	}
 
 	// Parse the incoming JSON payload
 	var feedback struct {
-		ReviewID int    `json:"review_id"`
-		Comment  string `json:"comment"`
+		ReviewID int                        `json:"review_id" validate:"required,min=1"`
+		Comment  string                     `json:"comment" validate:"max=10000"`
+		Answers  map[string]json.RawMessage `json:"answers"` // Answers keyed by template question ID
 	}
 
 	defer func() {
@@ -112,34 +131,53 @@ func SubmitFeedback(w http.ResponseWriter, r *http.Request) {
 			log.Printf("Error closing request body: %v", closeErr)
 		}
 	}()
-	if err := json.NewDecoder(r.Body).Decode(&feedback); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if !decodePayload(w, r, &feedback) {
 		return
 	}
 
-	// Validate that the employee is authorized to review the given review
-	var isReviewer bool
-	err := db.Conn.QueryRowContext(r.Context(),
-		"SELECT EXISTS(SELECT 1 FROM review_reviewers WHERE review_id = $1 AND reviewer_id = $2)",
-		feedback.ReviewID, employeeID,
-	).Scan(&isReviewer)
-	if err != nil {
-		http.Error(w, "Error validating reviewer status", http.StatusInternalServerError)
+	review, ok := h.checkFeedbackOpen(w, r, feedback.ReviewID, employee.ID)
+	if !ok {
 		return
 	}
 
-	if !isReviewer {
-		http.Error(w, "Unauthorized to review this performance review", http.StatusForbidden)
+	// Templated reviews must answer every required question
+	answers, err := reviewAnswers(r.Context(), h.templates, review, feedback.Answers, true)
+	if errors.Is(err, errAnswersInvalid) {
+		WriteError(w, r, http.StatusBadRequest, CodeAnswersInvalid, err.Error())
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review template")
 		return
 	}
 
-	// Append the feedback to the comments in the reviews table
-	_, err = db.Conn.ExecContext(r.Context(),
-		"UPDATE reviews SET comments = array_append(comments, $1) WHERE id = $2",
-		feedback.Comment, feedback.ReviewID,
-	)
+	// Record the feedback on the review, submitting over a saved draft if there is one
+	record := store.Feedback{
+		ReviewID:   feedback.ReviewID,
+		ReviewerID: employee.ID,
+		Body:       feedback.Comment,
+		Answers:    answers,
+		Submitted:  true,
+	}
+	_, err = h.feedback.Create(r.Context(), record)
+	if errors.Is(err, store.ErrFeedbackExists) {
+		var existing store.Feedback
+		existing, err = h.feedback.Get(r.Context(), record.ReviewID, record.ReviewerID)
+		if err == nil && !existing.Submitted {
+			_, err = h.feedback.Update(r.Context(), record, existing.Version)
+		} else if err == nil {
+			err = store.ErrFeedbackExists
+		}
+	}
+	if errors.Is(err, store.ErrFeedbackExists) || errors.Is(err, store.ErrFeedbackSubmitted) {
+		WriteError(w, r, http.StatusConflict, CodeFeedbackExists, "Feedback already submitted for this review")
+		return
+	}
+	if !h.writeFeedbackConflict(w, r, record.ReviewID, employee.ID, err) {
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error adding feedback to review", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error adding feedback to review")
 		return
 	}
 
@@ -148,6 +186,87 @@ func SubmitFeedback(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewEncoder(w).Encode(types.MessageResponse{
 		Message: "Feedback submitted successfully",
 	}); err != nil {
-		http.Error(w, "Error submitting feedback", http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error submitting feedback")
+	}
+}
+
+// ListReceivedReviews godoc
+// @Summary List reviews about yourself
+// @Description Lists the employee's own reviews that have been shared with them
+// @Tags Employee
+// @Produce json
+// @Param cycle_id query int false "Only reviews in this cycle"
+// @Success 200 {array} types.ReceivedReviewResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /employee/reviews/received [get]
+func (h *EmployeeHandler) ListReceivedReviews(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	filter, err := parseReviewFilter(r)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	filter.EmployeeID = employee.ID
+
+	stored, err := h.reviews.List(r.Context(), filter, store.Page{})
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reviews")
+		return
+	}
+	comments, err := loadComments(r.Context(), h.feedback, stored)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching feedback")
+		return
+	}
+
+	// Reviews only become visible to their subject once shared
+	reviews := []types.ReceivedReviewResponse{}
+	for _, review := range stored {
+		if review.Status != store.ReviewShared && review.Status != store.ReviewAcknowledged {
+			continue
+		}
+		// Reviewers stay anonymous to the reviewed employee
+		anonymous := make([]types.FeedbackResponse, 0, len(comments[review.ID]))
+		for _, comment := range comments[review.ID] {
+			comment.ReviewerID = 0
+			comment.ReviewerEmail = ""
+			anonymous = append(anonymous, comment)
+		}
+		reviews = append(reviews, types.ReceivedReviewResponse{
+			ID:                review.ID,
+			CycleID:           review.CycleID,
+			TemplateID:        review.TemplateID,
+			PerformanceReview: review.PerformanceReview,
+			Status:            review.Status,
+			Comments:          anonymous,
+			Ratings:           aggregateRatings(anonymous),
+			CreatedAt:         review.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reviews); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error encoding response for received reviews")
+	}
+}
+
+// currentEmployee resolves the employee record of the authenticated user
+func (h *EmployeeHandler) currentEmployee(r *http.Request) (store.Employee, error) {
+	return employeeFromClaims(r, h.employees)
+}
+
+// employeeFromClaims looks up the employee matching the claims added by auth
+func employeeFromClaims(r *http.Request, employees store.EmployeeStore) (store.Employee, error) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		return store.Employee{}, errors.New("missing claims")
 	}
+	return employees.GetByEmail(r.Context(), claims.Email)
 }
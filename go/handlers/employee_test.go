@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSubmitFeedback(t *testing.T) {
+	submit := func(body string, wantStatus int, wantCode string) testStep {
+		return testStep{method: http.MethodPost, path: "/employee/reviews/feedback", body: body, wantStatus: wantStatus, wantCode: wantCode}
+	}
+	tests := []struct {
+		name  string
+		steps []testStep
+	}{
+		{name: "first feedback", steps: []testStep{
+			submit(`{"review_id": 1, "comment": "Great work"}`, http.StatusCreated, ""),
+		}},
+		{name: "second feedback", steps: []testStep{
+			submit(`{"review_id": 1, "comment": "Great work"}`, http.StatusCreated, ""),
+			submit(`{"review_id": 1, "comment": "Again"}`, http.StatusConflict, CodeFeedbackExists),
+		}},
+		{name: "over a draft", steps: []testStep{
+			{method: http.MethodPut, path: "/employee/reviews/1/feedback/draft", body: `{"body": "Draft", "version": 0}`, wantStatus: http.StatusOK},
+			submit(`{"review_id": 1, "comment": "Final"}`, http.StatusCreated, ""),
+		}},
+		{name: "review of someone else", steps: []testStep{
+			submit(`{"review_id": 999, "comment": "Great work"}`, http.StatusForbidden, CodeNotAReviewer),
+		}},
+		{name: "no review", steps: []testStep{
+			submit(`{"comment": "Great work"}`, http.StatusUnprocessableEntity, CodeValidationFailed),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, newTestFeedback(t, openCycle()), tt.steps)
+		})
+	}
+}
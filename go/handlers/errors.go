@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"go-api/types"
+)
+
+// Error codes returned in the code field of error responses. Clients should
+// branch on these rather than on messages, which may change.
+const (
+	// Generic codes
+	CodeInvalidRequest          = "invalid_request"          // malformed body, path or query parameter
+	CodeValidationFailed        = "validation_failed"        // well-formed request with invalid values, see details.fields
+	CodeConflict                = "conflict"                 // the request would duplicate a unique record
+	CodeInvalidReference        = "invalid_reference"        // the request refers to a record that does not exist
+	CodePreconditionFailed      = "precondition_failed"      // If-Match names a version the record no longer has
+	CodeIdempotencyKeyReused    = "idempotency_key_reused"   // the Idempotency-Key was sent with a different request before
+	CodeIdempotencyKeyInUse     = "idempotency_key_in_use"   // the first request with the Idempotency-Key has not finished
+	CodeUnauthorized            = "unauthorized"             // missing or unusable credentials
+	CodeInsufficientPermissions = "insufficient_permissions" // authenticated but lacking a permission
+	CodeInternal                = "internal_error"           // unexpected server failure; see the logs for request_id
+
+	// Authentication
+	CodeInvalidCredentials  = "invalid_credentials"
+	CodeTooManyAttempts     = "too_many_attempts"
+	CodeTokenInvalid        = "token_invalid"
+	CodeTokenRevoked        = "token_revoked"
+	CodeRefreshTokenInvalid = "refresh_token_invalid"
+	CodeAPIKeyInvalid       = "api_key_invalid"
+	CodeAPIKeyNotAllowed    = "api_key_not_allowed"
+	CodeAPIKeyNotFound      = "api_key_not_found"
+	CodeMFATokenInvalid     = "mfa_token_invalid"
+	CodeMFACodeInvalid      = "mfa_code_invalid"
+	CodeMFAAlreadyEnabled   = "mfa_already_enabled"
+	CodeMFANotPending       = "mfa_not_pending"
+	CodeMFARequiredByRole   = "mfa_required_by_role"
+	CodePasswordIncorrect   = "password_incorrect"
+	CodeResetTokenInvalid   = "reset_token_invalid"
+	CodeSSOFailed           = "sso_failed"
+	CodeSSOExpired          = "sso_expired"
+	CodeSSOAccountNotFound  = "sso_account_not_found"
+	CodeAccountNotFound     = "account_not_found"
+
+	// Employees and roles
+	CodeEmployeeNotFound   = "employee_not_found"
+	CodeEmployeeEmailTaken = "employee_email_taken"
+	CodeManagerCycle       = "manager_cycle"
+	CodeNotAReport         = "not_a_report"
+	CodeRoleNotFound       = "role_not_found"
+
+	// Reviews
+	CodeReviewNotFound          = "review_not_found"
+	CodeReviewLocked            = "review_locked"
+	CodeReviewNotOpen           = "review_not_open"
+	CodeReviewStatusChanged     = "review_status_changed"
+	CodeTransitionInvalid       = "transition_invalid"
+	CodeTransitionNotAllowed    = "transition_not_allowed"
+	CodeNotAReviewer            = "not_a_reviewer"
+	CodeReviewerNotFound        = "reviewer_not_found"
+	CodeReviewJobNotFound       = "review_job_not_found"
+	CodeNotReviewSubject        = "not_review_subject"
+	CodeCycleNotFound           = "cycle_not_found"
+	CodeCycleClosed             = "cycle_closed"
+	CodeCycleNotStarted         = "cycle_not_started"
+	CodeFeedbackDeadlinePassed  = "feedback_deadline_passed"
+	CodeCycleInUse              = "cycle_in_use"
+	CodeTemplateNotFound        = "template_not_found"
+	CodeTemplateInUse           = "template_in_use"
+	CodeReviewHasNoTemplate     = "review_has_no_template"
+	CodeAnswersInvalid          = "answers_invalid"
+	CodeFeedbackNotFound        = "feedback_not_found"
+	CodeFeedbackExists          = "feedback_exists"
+	CodeFeedbackSubmitted       = "feedback_submitted"
+	CodeFeedbackVersionConflict = "feedback_version_conflict"
+)
+
+// problemContentType is the media type of RFC 7807 problem details
+const problemContentType = "application/problem+json"
+
+const requestIDKey contextKey = "request_id"
+
+// WithRequestID returns a copy of ctx carrying the ID of the request, which
+// error responses echo so they can be matched with the logs
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the ID stored by the request ID middleware
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WriteError responds with an RFC 7807 problem document carrying one of the
+// error codes above. It is used for every failure instead of http.Error.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	WriteErrorDetails(w, r, status, code, message, nil)
+}
+
+// WriteErrorDetails is WriteError with details about the failure, such as
+// which value was invalid
+func WriteErrorDetails(w http.ResponseWriter, r *http.Request, status int, code, message string, details map[string]any) {
+	requestID := RequestIDFromContext(r.Context())
+	if status >= http.StatusInternalServerError {
+		log.Printf("Request %s failed with %s: %s", requestID, code, message)
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(types.ErrorResponse{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    message,
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: requestID,
+	})
+	if err != nil {
+		log.Printf("Error encoding error response: %v", err)
+	}
+}
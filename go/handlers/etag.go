@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag formats a record version as a strong entity tag, e.g. "3"
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatch reads the If-Match header of a write, returning the version the
+// client last read or 0 when the header is absent or *, which leaves the write
+// unconditional. A weak or malformed tag can never match, so it gets a 412; a
+// list of tags gets a 400 as only one version is checked. false is returned
+// when a response was written.
+func ifMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if strings.Contains(header, ",") {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "If-Match must be * or a single ETag")
+		return 0, false
+	}
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`))
+	if err != nil || header != etag(version) {
+		writePreconditionFailed(w, r)
+		return 0, false
+	}
+	return version, true
+}
+
+// notModified sets the ETag of a GET response and answers 304 when the
+// If-None-Match header names the current version or is *. It returns true
+// when the 304 was written.
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	current := etag(version)
+	w.Header().Set("ETag", current)
+
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		// If-None-Match compares weakly, so W/"3" matches "3"
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// writePreconditionFailed responds 412 to a write whose If-Match names a
+// version the record no longer has
+func writePreconditionFailed(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, http.StatusPreconditionFailed, CodePreconditionFailed, "Record was changed since it was read; fetch it again and retry")
+}
@@ -0,0 +1,460 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go-api/store"
+	"go-api/types"
+)
+
+// /reviews/{id}/feedback handlers
+
+// GetFeedback godoc
+// @Summary Get your feedback on a review
+// @Description Returns the feedback the employee wrote on a review
+// @Tags Employee
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} types.FeedbackResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /employee/reviews/{id}/feedback [get]
+func (h *EmployeeHandler) GetFeedback(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
+	}
+
+	feedback, err := h.feedback.Get(r.Context(), reviewID, employee.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeFeedbackNotFound, "Feedback not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching feedback")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(feedbackResponse(feedback)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// UpdateFeedback godoc
+// @Summary Write or edit your feedback on a review
+// @Description Writes the employee's feedback on a review that is open for feedback and submits it
+// @Description in one step, replacing their draft if there is one. The version must be 0 when there
+// @Description is no draft yet or match the last saved draft. Submitted feedback can no longer be edited.
+// @Tags Employee
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param feedback body object true "Feedback body and draft version"
+// @Success 200 {object} types.FeedbackResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /employee/reviews/{id}/feedback [put]
+func (h *EmployeeHandler) UpdateFeedback(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Body    string                     `json:"body" validate:"max=10000"`
+		Answers map[string]json.RawMessage `json:"answers"`
+		Version int                        `json:"version" validate:"min=0"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	review, ok := h.checkFeedbackOpen(w, r, reviewID, employee.ID)
+	if !ok {
+		return
+	}
+	answers, err := reviewAnswers(r.Context(), h.templates, review, payload.Answers, true)
+	if errors.Is(err, errAnswersInvalid) {
+		WriteError(w, r, http.StatusBadRequest, CodeAnswersInvalid, err.Error())
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review template")
+		return
+	}
+
+	feedback := store.Feedback{
+		ReviewID:   reviewID,
+		ReviewerID: employee.ID,
+		Body:       payload.Body,
+		Answers:    answers,
+		Submitted:  true,
+	}
+	var saved store.Feedback
+	if payload.Version == 0 {
+		saved, err = h.feedback.Create(r.Context(), feedback)
+		if errors.Is(err, store.ErrFeedbackExists) {
+			// The feedback was written elsewhere since the client last loaded it
+			var existing store.Feedback
+			existing, err = h.feedback.Get(r.Context(), reviewID, employee.ID)
+			if err == nil && existing.Submitted {
+				err = store.ErrFeedbackSubmitted
+			} else if err == nil {
+				err = store.ErrVersionMismatch
+			}
+		}
+	} else {
+		saved, err = h.feedback.Update(r.Context(), feedback, payload.Version)
+		if errors.Is(err, store.ErrNotFound) {
+			// The draft was retracted since the client last loaded it
+			err = store.ErrVersionMismatch
+		}
+	}
+	if !h.writeFeedbackConflict(w, r, reviewID, employee.ID, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error saving feedback: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error saving feedback")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(feedbackResponse(saved)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// SaveFeedbackDraft godoc
+// @Summary Save a draft of your feedback on a review
+// @Description Creates or overwrites the employee's unsubmitted feedback. Send the version of the draft
+// @Description you last saved, or 0 for a new draft; a stale version is rejected with 409 so that edits
+// @Description from another tab are not lost. Drafts are not visible to anyone else.
+// @Tags Employee
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param draft body object true "Draft body and version"
+// @Success 200 {object} types.FeedbackResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /employee/reviews/{id}/feedback/draft [put]
+func (h *EmployeeHandler) SaveFeedbackDraft(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Body    string                     `json:"body" validate:"max=10000"`
+		Answers map[string]json.RawMessage `json:"answers"`
+		Version int                        `json:"version" validate:"min=0"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	review, ok := h.checkFeedbackOpen(w, r, reviewID, employee.ID)
+	if !ok {
+		return
+	}
+	// Drafts may leave required questions unanswered until they are submitted
+	answers, err := reviewAnswers(r.Context(), h.templates, review, payload.Answers, false)
+	if errors.Is(err, errAnswersInvalid) {
+		WriteError(w, r, http.StatusBadRequest, CodeAnswersInvalid, err.Error())
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review template")
+		return
+	}
+
+	saved, err := h.feedback.SaveDraft(r.Context(), store.Feedback{
+		ReviewID:   reviewID,
+		ReviewerID: employee.ID,
+		Body:       payload.Body,
+		Answers:    answers,
+	}, payload.Version)
+	if !h.writeFeedbackConflict(w, r, reviewID, employee.ID, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error saving feedback draft: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error saving feedback draft")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(feedbackResponse(saved)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// SubmitFeedbackDraft godoc
+// @Summary Submit your draft feedback on a review
+// @Description Locks the employee's draft so it can no longer be autosaved and shares it with the
+// @Description review. The version must match the last saved draft.
+// @Tags Employee
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param submit body object true "Version of the draft being submitted"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 200 {object} types.FeedbackResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /employee/reviews/{id}/feedback/submit [post]
+func (h *EmployeeHandler) SubmitFeedbackDraft(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Version int `json:"version" validate:"required,min=1"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	review, ok := h.checkFeedbackOpen(w, r, reviewID, employee.ID)
+	if !ok {
+		return
+	}
+
+	// Check the draft being submitted answers every required question
+	draft, err := h.feedback.Get(r.Context(), reviewID, employee.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeFeedbackNotFound, "Feedback draft not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching feedback")
+		return
+	}
+	if draft.Version == payload.Version && !draft.Submitted {
+		err = checkAnswersComplete(r.Context(), h.templates, review, draft.Answers)
+		if errors.Is(err, errAnswersInvalid) {
+			WriteError(w, r, http.StatusBadRequest, CodeAnswersInvalid, err.Error())
+			return
+		}
+		if err != nil {
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review template")
+			return
+		}
+	}
+
+	submitted, err := h.feedback.Submit(r.Context(), reviewID, employee.ID, payload.Version)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeFeedbackNotFound, "Feedback draft not found")
+		return
+	}
+	if !h.writeFeedbackConflict(w, r, reviewID, employee.ID, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error submitting feedback: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error submitting feedback")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(feedbackResponse(submitted)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// writeFeedbackConflict writes a 409 response for draft version and lock errors
+// and returns false, or returns true when err is neither
+func (h *EmployeeHandler) writeFeedbackConflict(w http.ResponseWriter, r *http.Request, reviewID, employeeID int, err error) bool {
+	switch {
+	case errors.Is(err, store.ErrFeedbackSubmitted):
+		WriteError(w, r, http.StatusConflict, CodeFeedbackSubmitted, "Feedback already submitted")
+		return false
+	case errors.Is(err, store.ErrVersionMismatch):
+		// Tell the client which version to reload so it can merge its changes
+		current, getErr := h.feedback.Get(r.Context(), reviewID, employeeID)
+		if getErr != nil {
+			WriteError(w, r, http.StatusConflict, CodeFeedbackVersionConflict, "Feedback draft was changed elsewhere")
+		} else {
+			WriteErrorDetails(w, r, http.StatusConflict, CodeFeedbackVersionConflict, fmt.Sprintf("Feedback draft was changed elsewhere, current version is %d", current.Version), map[string]any{"current_version": current.Version})
+		}
+		return false
+	}
+	return true
+}
+
+// RetractFeedback godoc
+// @Summary Retract your draft feedback on a review
+// @Description Deletes the employee's draft feedback on a review that is still open for feedback.
+// @Description Submitted feedback is locked and cannot be retracted.
+// @Tags Employee
+// @Param id path int true "Review ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /employee/reviews/{id}/feedback [delete]
+func (h *EmployeeHandler) RetractFeedback(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
+	}
+
+	if _, ok := h.checkFeedbackOpen(w, r, reviewID, employee.ID); !ok {
+		return
+	}
+
+	err = h.feedback.Delete(r.Context(), reviewID, employee.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeFeedbackNotFound, "Feedback not found")
+		return
+	}
+	if !h.writeFeedbackConflict(w, r, reviewID, employee.ID, err) {
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error retracting feedback")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkFeedbackOpen verifies that the employee reviews the review, that it is in
+// progress and that its cycle accepts feedback, returning the review. Otherwise it writes
+// the error response and returns false.
+func (h *EmployeeHandler) checkFeedbackOpen(w http.ResponseWriter, r *http.Request, reviewID, employeeID int) (store.Review, bool) {
+	// Validate that the employee is authorized to review the given review
+	isReviewer, err := h.reviews.IsReviewer(r.Context(), reviewID, employeeID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error validating reviewer status")
+		return store.Review{}, false
+	}
+
+	if !isReviewer {
+		WriteError(w, r, http.StatusForbidden, CodeNotAReviewer, "Unauthorized to review this performance review")
+		return store.Review{}, false
+	}
+
+	// Feedback is only accepted while the review is in progress and its cycle accepts it
+	review, err := h.reviews.Get(r.Context(), reviewID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review")
+		return store.Review{}, false
+	}
+	if review.Status != store.ReviewInProgress {
+		WriteError(w, r, http.StatusConflict, CodeReviewNotOpen, "Review is not open for feedback")
+		return store.Review{}, false
+	}
+	cycle, err := h.cycles.Get(r.Context(), review.CycleID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review cycle")
+		return store.Review{}, false
+	}
+	now := time.Now()
+	if !cycle.AcceptsFeedback(now) {
+		switch {
+		case cycle.IsClosed(now):
+			WriteError(w, r, http.StatusConflict, CodeCycleClosed, "Review cycle is closed")
+		case !cycle.HasStarted(now):
+			WriteError(w, r, http.StatusConflict, CodeCycleNotStarted, "Review cycle has not started")
+		default:
+			WriteError(w, r, http.StatusConflict, CodeFeedbackDeadlinePassed, "Peer-review deadline of the review cycle has passed")
+		}
+		return store.Review{}, false
+	}
+	return review, true
+}
+
+// loadComments fetches the submitted feedback on the given reviews keyed by review ID
+func loadComments(ctx context.Context, feedbackStore store.FeedbackStore, reviews []store.Review) (map[int][]types.FeedbackResponse, error) {
+	comments := map[int][]types.FeedbackResponse{}
+	reviewIDs := make([]int, 0, len(reviews))
+	for _, review := range reviews {
+		reviewIDs = append(reviewIDs, review.ID)
+		comments[review.ID] = []types.FeedbackResponse{}
+	}
+
+	feedback, err := feedbackStore.ListForReviews(ctx, reviewIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range feedback {
+		if item.Submitted {
+			comments[item.ReviewID] = append(comments[item.ReviewID], feedbackResponse(item))
+		}
+	}
+	return comments, nil
+}
+
+func feedbackResponse(feedback store.Feedback) types.FeedbackResponse {
+	return types.FeedbackResponse{
+		ID:            feedback.ID,
+		ReviewID:      feedback.ReviewID,
+		ReviewerID:    feedback.ReviewerID,
+		ReviewerEmail: feedback.ReviewerEmail,
+		Body:          feedback.Body,
+		Submitted:     feedback.Submitted,
+		Answers:       answerResponses(feedback.Answers),
+		Version:       feedback.Version,
+		CreatedAt:     feedback.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:     feedback.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/store"
+	"go-api/store/memory"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// openCycle returns a cycle that started an hour ago and is collecting feedback
+func openCycle() store.Cycle {
+	now := time.Now()
+	return store.Cycle{
+		Name:               "Current",
+		StartsAt:           now.Add(-time.Hour),
+		SelfReviewDeadline: now.Add(24 * time.Hour),
+		PeerReviewDeadline: now.Add(48 * time.Hour),
+		ClosesAt:           now.Add(72 * time.Hour),
+		Status:             store.CycleOpen,
+	}
+}
+
+// newTestFeedback serves the feedback routes from an in-memory backend holding
+// review 1 of employee 1 in the given cycle, with employee 2 as its reviewer
+// signed in
+func newTestFeedback(t *testing.T, cycle store.Cycle) http.Handler {
+	t.Helper()
+	ctx := context.Background()
+	stores := memory.New()
+
+	for _, email := range []string{"employee1@example.com", "employee2@example.com"} {
+		if _, err := stores.Employees.Create(ctx, store.Employee{Email: email, Position: "Developer"}, "not-a-real-hash"); err != nil {
+			t.Fatalf("creating employee: %v", err)
+		}
+	}
+	cycle, err := stores.Cycles.Create(ctx, cycle)
+	if err != nil {
+		t.Fatalf("creating cycle: %v", err)
+	}
+	reviewID, err := stores.Reviews.Create(ctx, store.Review{CycleID: cycle.ID, EmployeeID: 1, ReviewerIDs: []int{2}})
+	if err != nil {
+		t.Fatalf("creating review: %v", err)
+	}
+	if _, err := stores.Reviews.Transition(ctx, reviewID, store.ReviewDraft, store.ReviewInProgress, 0); err != nil {
+		t.Fatalf("starting review: %v", err)
+	}
+
+	h := NewEmployeeHandler(stores)
+	r := router.NewRouter()
+	r.Post("/employee/reviews/feedback", h.SubmitFeedback)
+	r.Put("/employee/reviews/{id}/feedback", h.UpdateFeedback)
+	r.Delete("/employee/reviews/{id}/feedback", h.RetractFeedback)
+	r.Put("/employee/reviews/{id}/feedback/draft", h.SaveFeedbackDraft)
+	return signedIn(r, "employee2@example.com")
+}
+
+// signedIn serves next as the user with the given email
+func signedIn(next http.Handler, email string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), &Claims{Email: email})))
+	})
+}
+
+// testStep is one request of a sequence sent to the same handler and the
+// status and error code it must get
+type testStep struct {
+	method     string
+	path       string
+	body       string
+	wantStatus int
+	wantCode   string
+}
+
+func runSteps(t *testing.T, handler http.Handler, steps []testStep) {
+	t.Helper()
+	for i, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != step.wantStatus {
+			t.Fatalf("step %d: %s %s: status = %d, want %d; body %s", i+1, step.method, step.path, rec.Code, step.wantStatus, rec.Body)
+		}
+		if step.wantCode != "" {
+			if code := errorCode(t, rec); code != step.wantCode {
+				t.Fatalf("step %d: %s %s: code = %q, want %q", i+1, step.method, step.path, code, step.wantCode)
+			}
+		}
+	}
+}
+
+func TestRetractFeedback(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []testStep
+	}{
+		{
+			name: "draft",
+			steps: []testStep{
+				{method: http.MethodPut, path: "/employee/reviews/1/feedback/draft", body: `{"body": "Draft", "version": 0}`, wantStatus: http.StatusOK},
+				{method: http.MethodDelete, path: "/employee/reviews/1/feedback", wantStatus: http.StatusNoContent},
+				{method: http.MethodDelete, path: "/employee/reviews/1/feedback", wantStatus: http.StatusNotFound, wantCode: CodeFeedbackNotFound},
+			},
+		},
+		{
+			name: "submitted",
+			steps: []testStep{
+				{method: http.MethodPost, path: "/employee/reviews/feedback", body: `{"review_id": 1, "comment": "Great work"}`, wantStatus: http.StatusCreated},
+				{method: http.MethodPut, path: "/employee/reviews/1/feedback", body: `{"body": "Edited", "version": 2}`, wantStatus: http.StatusConflict, wantCode: CodeFeedbackSubmitted},
+				{method: http.MethodDelete, path: "/employee/reviews/1/feedback", wantStatus: http.StatusConflict, wantCode: CodeFeedbackSubmitted},
+				{method: http.MethodPut, path: "/employee/reviews/1/feedback", body: `{"body": "Rewritten", "version": 0}`, wantStatus: http.StatusConflict, wantCode: CodeFeedbackSubmitted},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, newTestFeedback(t, openCycle()), tt.steps)
+		})
+	}
+}
+
+func TestSubmitFeedbackCycleDates(t *testing.T) {
+	tests := []struct {
+		name       string
+		cycle      func(c store.Cycle) store.Cycle
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "open", wantStatus: http.StatusCreated},
+		{name: "draft", cycle: func(c store.Cycle) store.Cycle { c.Status = store.CycleDraft; return c }, wantStatus: http.StatusConflict, wantCode: CodeCycleNotStarted},
+		{name: "before start", cycle: func(c store.Cycle) store.Cycle { c.StartsAt = time.Now().Add(time.Hour); return c }, wantStatus: http.StatusConflict, wantCode: CodeCycleNotStarted},
+		{name: "after peer-review deadline", cycle: func(c store.Cycle) store.Cycle {
+			c.StartsAt = time.Now().Add(-48 * time.Hour)
+			c.SelfReviewDeadline = time.Now().Add(-24 * time.Hour)
+			c.PeerReviewDeadline = time.Now().Add(-time.Hour)
+			return c
+		}, wantStatus: http.StatusConflict, wantCode: CodeFeedbackDeadlinePassed},
+		{name: "closed", cycle: func(c store.Cycle) store.Cycle { c.Status = store.CycleClosed; return c }, wantStatus: http.StatusConflict, wantCode: CodeCycleClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle := openCycle()
+			if tt.cycle != nil {
+				cycle = tt.cycle(cycle)
+			}
+			runSteps(t, newTestFeedback(t, cycle), []testStep{
+				{method: http.MethodPost, path: "/employee/reviews/feedback", body: `{"review_id": 1, "comment": "Great work"}`, wantStatus: tt.wantStatus, wantCode: tt.wantCode},
+			})
+		})
+	}
+}
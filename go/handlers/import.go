@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-api/store"
+	"go-api/types"
+	"go-api/validate"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// maxImportRows bounds the employees of one import
+const maxImportRows = 1000
+
+// maxImportLineLength bounds a line of a JSON Lines import
+const maxImportLineLength = 64 * 1024
+
+// importColumns are the columns a CSV import may have, in the order they are documented
+var importColumns = []string{"email", "position", "manager_email", "password", "invite"}
+
+// errUnsupportedImport is returned for imports in a format other than CSV or JSON Lines
+var errUnsupportedImport = errors.New("Content-Type must be text/csv or application/x-ndjson")
+
+// Results of import rows
+const (
+	importCreated   = "created"
+	importUpdated   = "updated"
+	importUnchanged = "unchanged"
+	importInvalid   = "invalid"
+)
+
+// employeeImportRow is one employee of an import file. Password and invite
+// only apply to new employees, which need exactly one of them.
+type employeeImportRow struct {
+	Email        string `json:"email" validate:"required,email,max=254"`
+	Position     string `json:"position" validate:"required,max=100"`
+	ManagerEmail string `json:"manager_email" validate:"email,max=254"`
+	Password     string `json:"password"`
+	Invite       bool   `json:"invite"`
+}
+
+// importLine is a row of an import together with what became of it
+type importLine struct {
+	line int
+	row  employeeImportRow
+	// errs holds the problems of the row; "row" is used when it could not be read
+	errs       validate.Errors
+	result     string
+	employeeID int
+	invited    bool
+}
+
+// ImportEmployees godoc
+// @Summary Import employees
+// @Description Adds or updates employees in bulk from CSV with a header row naming the columns, or from JSON Lines with one object per line. Fields are email, position, manager_email, password and invite; new employees need a password or invite=true, which emails them a link to choose one. With dry_run=true nothing is stored and the report says what would happen to each row; otherwise all rows are stored in one transaction, or none when any is invalid.
+// @Tags Admin
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param employees body string true "Employees as CSV or JSON Lines, at most 1000"
+// @Param dry_run query bool false "Only check the rows and report what would be done"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 200 {object} types.EmployeeImportResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 415 {object} types.ErrorResponse "Unsupported Media Type"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/employees/import [post]
+func (h *AdminHandler) ImportEmployees(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := router.URLQuery(r, "dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "invalid dry_run, must be true or false")
+			return
+		}
+	}
+
+	lines, err := readImport(r)
+	if errors.Is(err, errUnsupportedImport) {
+		WriteError(w, r, http.StatusUnsupportedMediaType, CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if len(lines) == 0 {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "No employees to import")
+		return
+	}
+
+	if err := h.checkImport(r.Context(), lines); err != nil {
+		log.Printf("Error checking employee import: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error checking employees")
+		return
+	}
+	if dryRun {
+		writeImportReport(w, r, lines, true)
+		return
+	}
+
+	report := importReport(lines, false)
+	if report.Invalid > 0 {
+		invalid := slices.DeleteFunc(report.Rows, func(row types.EmployeeImportRowResponse) bool { return row.Result != importInvalid })
+		WriteErrorDetails(w, r, http.StatusUnprocessableEntity, CodeValidationFailed,
+			fmt.Sprintf("%d of %d rows are invalid, nothing was imported", report.Invalid, len(lines)),
+			map[string]any{"rows": invalid})
+		return
+	}
+
+	imports, err := hashImportPasswords(lines)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error hashing password")
+		return
+	}
+	imported, err := h.employees.Import(r.Context(), imports)
+	switch {
+	case errors.Is(err, store.ErrEmailTaken):
+		WriteError(w, r, http.StatusConflict, CodeEmployeeEmailTaken, "An account was added meanwhile with an imported email, nothing was imported")
+		return
+	case errors.Is(err, store.ErrNotFound):
+		WriteError(w, r, http.StatusConflict, CodeEmployeeNotFound, "A manager was removed meanwhile, nothing was imported")
+		return
+	case errors.Is(err, store.ErrManagerCycle):
+		WriteError(w, r, http.StatusConflict, CodeManagerCycle, "Reporting lines changed meanwhile and would loop, nothing was imported")
+		return
+	case err != nil:
+		log.Printf("Error importing employees: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error importing employees")
+		return
+	}
+
+	for i, employee := range imported {
+		line := &lines[i]
+		line.employeeID = employee.ID
+		switch {
+		case employee.Created:
+			line.result = importCreated
+		case employee.Changed:
+			line.result = importUpdated
+		default:
+			line.result = importUnchanged
+		}
+		// The employees are stored by now, so a failed email is only logged;
+		// they can still ask for a link through POST /password/forgot
+		if employee.Created && line.row.Invite {
+			if err := h.invites.invite(r.Context(), employee.Email); err != nil {
+				log.Printf("Error inviting %s: %v", employee.Email, err)
+				continue
+			}
+			line.invited = true
+		}
+	}
+	writeImportReport(w, r, lines, false)
+}
+
+// readImport reads the rows of an import in the format its Content-Type names
+func readImport(r *http.Request) ([]importLine, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, errUnsupportedImport
+	}
+	switch mediaType {
+	case "text/csv":
+		return readImportCSV(r.Body)
+	case "application/x-ndjson", "application/jsonl":
+		return readImportJSONLines(r.Body)
+	}
+	return nil, errUnsupportedImport
+}
+
+// readImportCSV reads CSV whose first line names the columns. Rows that
+// cannot be read are returned with the problem under "row".
+func readImportCSV(body io.Reader) ([]importLine, error) {
+	reader := csv.NewReader(body)
+	// Rows with the wrong number of fields are reported along with the others
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(importColumns, name) {
+			return nil, fmt.Errorf("unknown column %q, columns are %s", name, strings.Join(importColumns, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("column %q is given twice", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, errors.New("the header must name the email column")
+	}
+
+	var lines []importLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if len(lines) == maxImportRows {
+			return nil, fmt.Errorf("at most %d employees can be imported at once", maxImportRows)
+		}
+
+		entry := importLine{errs: validate.Errors{}}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			entry.line = parseErr.StartLine
+			entry.errs.Add("row", parseErr.Err.Error())
+			lines = append(lines, entry)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry.line, _ = reader.FieldPos(0)
+		if len(record) != len(header) {
+			entry.errs.Add("row", fmt.Sprintf("has %d fields but the header names %d", len(record), len(header)))
+			lines = append(lines, entry)
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return record[i]
+			}
+			return ""
+		}
+		entry.row = employeeImportRow{
+			Email:        strings.TrimSpace(field("email")),
+			Position:     strings.TrimSpace(field("position")),
+			ManagerEmail: strings.TrimSpace(field("manager_email")),
+			Password:     field("password"),
+		}
+		if invite := strings.TrimSpace(field("invite")); invite != "" {
+			if entry.row.Invite, err = strconv.ParseBool(invite); err != nil {
+				entry.errs.Add("invite", "must be true or false")
+			}
+		}
+		lines = append(lines, entry)
+	}
+}
+
+// readImportJSONLines reads one JSON object per line, skipping blank lines.
+// Lines that are not such an object are returned with the problem under "row".
+func readImportJSONLines(body io.Reader) ([]importLine, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, maxImportLineLength)
+
+	var lines []importLine
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(lines) == maxImportRows {
+			return nil, fmt.Errorf("at most %d employees can be imported at once", maxImportRows)
+		}
+
+		entry := importLine{line: number, errs: validate.Errors{}}
+		if err := json.Unmarshal([]byte(text), &entry.row); err != nil {
+			entry.errs.Add("row", "must be a JSON object with the employee's fields")
+		}
+		lines = append(lines, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid JSON Lines: %w", err)
+	}
+	return lines, nil
+}
+
+// checkImport validates every row like AddEmployee does, and against the
+// other rows and the stored employees, recording the problems in the row's
+// errs and what storing it would do in its result
+func (h *AdminHandler) checkImport(ctx context.Context, lines []importLine) error {
+	stored, err := h.employees.List(ctx, store.EmployeeFilter{}, store.Page{})
+	if err != nil {
+		return err
+	}
+	existing := map[string]store.Employee{}
+	emails := map[int]string{}
+	for _, employee := range stored {
+		existing[employee.Email] = employee
+		emails[employee.ID] = employee.Email
+	}
+	// managers maps each email to its manager's as they will be after the import
+	managers := map[string]string{}
+	for _, employee := range stored {
+		if employee.ManagerID != 0 {
+			managers[employee.Email] = emails[employee.ManagerID]
+		}
+	}
+
+	firstLines := map[string]int{}
+	for i := range lines {
+		line := &lines[i]
+		if len(line.errs) > 0 {
+			continue
+		}
+		for field, message := range validate.Struct(&line.row) {
+			line.errs.Add(field, message)
+		}
+		if len(line.errs) > 0 {
+			continue
+		}
+
+		row := line.row
+		if first, ok := firstLines[row.Email]; ok {
+			line.errs.Add("email", fmt.Sprintf("is already imported on line %d", first))
+			continue
+		}
+		firstLines[row.Email] = line.line
+
+		if employee, ok := existing[row.Email]; ok {
+			line.employeeID = employee.ID
+			line.result = importUpdated
+			if employee.Position == row.Position && (row.ManagerEmail == "" || row.ManagerEmail == managers[row.Email]) {
+				line.result = importUnchanged
+			}
+		} else {
+			line.result = importCreated
+			switch {
+			case row.Password != "" && row.Invite:
+				line.errs.Add("invite", "must be false when a password is given")
+			case row.Password == "" && !row.Invite:
+				line.errs.Add("password", "is required unless invite is true")
+			case row.Password != "":
+				if err := h.policy.Check(row.Password); err != nil {
+					line.errs.Add("password", err.Error())
+				}
+			}
+			_, err := h.users.GetByEmail(ctx, row.Email)
+			if err == nil {
+				line.errs.Add("email", "is used by an account that is not an employee")
+			} else if !errors.Is(err, store.ErrNotFound) {
+				return err
+			}
+		}
+		if row.ManagerEmail != "" {
+			managers[row.Email] = row.ManagerEmail
+		}
+	}
+
+	// Managers are checked once every row is known as they may be imported after their reports
+	for i := range lines {
+		line := &lines[i]
+		row := line.row
+		if len(line.errs) > 0 || row.ManagerEmail == "" {
+			continue
+		}
+		_, exists := existing[row.ManagerEmail]
+		_, imported := firstLines[row.ManagerEmail]
+		switch {
+		case row.ManagerEmail == row.Email:
+			line.errs.Add("manager_email", "must not be the employee themselves")
+		case !exists && !imported:
+			line.errs.Add("manager_email", "no employee uses this email")
+		case reportsTo(managers, row.ManagerEmail, row.Email):
+			line.errs.Add("manager_email", "reports to the employee, directly or indirectly")
+		}
+	}
+
+	for i := range lines {
+		if len(lines[i].errs) > 0 {
+			lines[i].result = importInvalid
+		}
+	}
+	return nil
+}
+
+// reportsTo reports whether walking up managers from email reaches manager
+func reportsTo(managers map[string]string, email, manager string) bool {
+	visited := map[string]bool{}
+	for current := email; current != "" && !visited[current]; current = managers[current] {
+		if current == manager {
+			return true
+		}
+		visited[current] = true
+	}
+	return false
+}
+
+// hashImportPasswords turns the checked rows into what the store imports,
+// hashing the passwords of new employees a few at a time as bcrypt is slow on purpose
+func hashImportPasswords(lines []importLine) ([]store.EmployeeImport, error) {
+	imports := make([]store.EmployeeImport, len(lines))
+	errs := make([]error, len(lines))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, 4)
+	for i, line := range lines {
+		imports[i] = store.EmployeeImport{
+			Email:        line.row.Email,
+			Position:     line.row.Position,
+			ManagerEmail: line.row.ManagerEmail,
+		}
+		if line.result != importCreated || line.row.Password == "" {
+			continue
+		}
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			imports[i].PasswordHash, errs[i] = hashPassword(line.row.Password)
+		})
+	}
+	wg.Wait()
+	return imports, errors.Join(errs...)
+}
+
+// importReport sums up the rows of an import
+func importReport(lines []importLine, dryRun bool) types.EmployeeImportResponse {
+	report := types.EmployeeImportResponse{DryRun: dryRun, Rows: make([]types.EmployeeImportRowResponse, len(lines))}
+	for i, line := range lines {
+		report.Rows[i] = types.EmployeeImportRowResponse{
+			Line:       line.line,
+			Email:      line.row.Email,
+			Result:     line.result,
+			EmployeeID: line.employeeID,
+			Invited:    line.invited,
+		}
+		switch line.result {
+		case importCreated:
+			report.Created++
+		case importUpdated:
+			report.Updated++
+		case importUnchanged:
+			report.Unchanged++
+		case importInvalid:
+			report.Invalid++
+			report.Rows[i].Errors = line.errs
+		}
+	}
+	return report
+}
+
+func writeImportReport(w http.ResponseWriter, r *http.Request, lines []importLine, dryRun bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(importReport(lines, dryRun)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
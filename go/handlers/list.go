@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api/store"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// Page sizes of list endpoints, set with ?limit=
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// sortable is a listed record, e.g. store.Employee or store.Review
+type sortable interface {
+	SortValue(field string) any
+}
+
+// pageCursor is what the opaque cursor of a list response encodes: the
+// position of the last item returned and the sort it was returned in, so a
+// cursor is not reused with a different sort
+type pageCursor struct {
+	Sort   string            `json:"sort"`
+	Values []json.RawMessage `json:"values"`
+	ID     int               `json:"id"`
+}
+
+// parsePage reads limit, sort and cursor from the query string. sort lists
+// fields separated by commas, each prefixed with - for descending order, e.g.
+// sort=created_at,-email; fields are the ones the listing allows and zero is
+// a record of it for decoding cursors. The returned page asks for one item
+// more than the limit so that nextCursor can tell whether another page follows.
+func parsePage(r *http.Request, fields []string, zero sortable) (store.Page, error) {
+	page := store.Page{Limit: defaultPageLimit}
+	if value := router.URLQuery(r, "limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, errors.New("invalid limit, must be between 1 and " + strconv.Itoa(maxPageLimit))
+		}
+		page.Limit = limit
+	}
+	page.Limit++
+
+	if sort := router.URLQuery(r, "sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			key := store.SortKey{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+			if !slices.Contains(fields, key.Field) {
+				return page, errors.New("invalid sort field " + strconv.Quote(key.Field) + ", must be one of " + strings.Join(fields, ", "))
+			}
+			page.Sort = append(page.Sort, key)
+		}
+	}
+
+	if value := router.URLQuery(r, "cursor"); value != "" {
+		after, err := decodeCursor(value, page.Sort, zero)
+		if err != nil {
+			return page, err
+		}
+		page.After = after
+	}
+	return page, nil
+}
+
+// nextCursor drops the extra item parsePage asked for and returns the cursor
+// of the page after items, or "" when items is the last page
+func nextCursor[T sortable](items []T, page store.Page) ([]T, string) {
+	if len(items) < page.Limit {
+		return items, ""
+	}
+	items = items[:page.Limit-1]
+	last := store.CursorOf(items[len(items)-1], page.Sort)
+
+	cursor := pageCursor{Sort: sortParam(page.Sort), ID: last.ID}
+	for _, value := range last.Values {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			panic(err)
+		}
+		cursor.Values = append(cursor.Values, encoded)
+	}
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		panic(err)
+	}
+	return items, base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeCursor reads a cursor returned by nextCursor for a listing sorted by keys
+func decodeCursor(value string, keys []store.SortKey, zero sortable) (*store.Cursor, error) {
+	invalid := errors.New("invalid cursor")
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, invalid
+	}
+	if cursor.Sort != sortParam(keys) {
+		return nil, errors.New("cursor was returned for a different sort")
+	}
+	if len(cursor.Values) != len(keys) {
+		return nil, invalid
+	}
+
+	after := &store.Cursor{ID: cursor.ID}
+	for i, key := range keys {
+		// Decode each value into the type the field has, e.g. time.Time for created_at
+		target := reflect.New(reflect.TypeOf(zero.SortValue(key.Field)))
+		if err := json.Unmarshal(cursor.Values[i], target.Interface()); err != nil {
+			return nil, invalid
+		}
+		after.Values = append(after.Values, target.Elem().Interface())
+	}
+	return after, nil
+}
+
+// sortParam formats keys the way the sort query parameter gives them
+func sortParam(keys []store.SortKey) string {
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		fields[i] = key.Field
+		if key.Desc {
+			fields[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// parseEmployeeFilter reads the employee list filters from the query string
+func parseEmployeeFilter(r *http.Request) (store.EmployeeFilter, error) {
+	filter := store.EmployeeFilter{Position: router.URLQuery(r, "position")}
+	var err error
+	if filter.CreatedAfter, err = queryTime(r, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = queryTime(r, "created_before"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// queryID reads a positive ID from the query string, returning zero when it is not given
+func queryID(r *http.Request, name string) (int, error) {
+	value := router.URLQuery(r, name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid " + name)
+	}
+	return id, nil
+}
+
+// queryTime reads an RFC 3339 time from the query string, returning the zero
+// time when it is not given
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	value := router.URLQuery(r, name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid " + name + ", must be an RFC 3339 time")
+	}
+	return t.UTC(), nil
+}
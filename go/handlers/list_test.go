@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go-api/store"
+)
+
+func TestCursor(t *testing.T) {
+	created := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	employees := []store.Employee{
+		{ID: 1, Email: "c@example.com", CreatedAt: created},
+		{ID: 2, Email: "b@example.com", CreatedAt: created},
+		{ID: 3, Email: "a@example.com", CreatedAt: created.Add(time.Hour)},
+	}
+	sort := []store.SortKey{{Field: "created_at"}, {Field: "email", Desc: true}}
+
+	// A limit of 2 asks the store for 3 items, so another page follows
+	items, cursor := nextCursor(employees, store.Page{Limit: 3, Sort: sort})
+	if len(items) != 2 || cursor == "" {
+		t.Fatalf("nextCursor() = %d items and cursor %q, want 2 items and a cursor", len(items), cursor)
+	}
+	if _, last := nextCursor(employees[:2], store.Page{Limit: 3, Sort: sort}); last != "" {
+		t.Errorf("nextCursor() on the last page = %q, want no cursor", last)
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		sort    []store.SortKey
+		want    *store.Cursor
+		wantErr string
+	}{
+		{name: "round trip", cursor: cursor, sort: sort, want: &store.Cursor{Values: []any{created, "b@example.com"}, ID: 2}},
+		{name: "other order", cursor: cursor, sort: []store.SortKey{{Field: "created_at"}, {Field: "email"}}, wantErr: "cursor was returned for a different sort"},
+		{name: "other fields", cursor: cursor, sort: []store.SortKey{{Field: "email"}}, wantErr: "cursor was returned for a different sort"},
+		{name: "not base64", cursor: "!!!", sort: sort, wantErr: "invalid cursor"},
+		{name: "not JSON", cursor: "bm90LWpzb24", sort: sort, wantErr: "invalid cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor, tt.sort, store.Employee{})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("decodeCursor() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCursor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api/store"
+	"go-api/types"
+
+	"github.com/jtclarkjr/router-go"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// LockoutDuration is how long logins are refused once the failure limit is reached
+	LockoutDuration = 15 * time.Minute
+	// failureWindow is how long failures are remembered without another one
+	failureWindow = 24 * time.Hour
+
+	// Accounts are slowed down after a few failures and locked after accountLockoutAfter
+	accountBackoffAfter = 3
+	accountLockoutAfter = 10
+	// Clients may be shared by many users behind one address, so they get more tries
+	ipBackoffAfter = 20
+	ipLockoutAfter = 100
+)
+
+// dummyPasswordHash is compared against for unknown emails so they take as long as known ones
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// backoff waits one second after `after` failures, doubling with every further
+// failure, and locks for LockoutDuration from lockoutAfter failures on
+func backoff(after, lockoutAfter int) store.Backoff {
+	return func(failures int) time.Duration {
+		switch {
+		case failures >= lockoutAfter:
+			return LockoutDuration
+		case failures < after:
+			return 0
+		}
+		return min(time.Second<<(failures-after), LockoutDuration)
+	}
+}
+
+// accountThrottleKey tracks an email whether or not an account uses it, so
+// lockouts do not reveal which emails exist
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// ipThrottleKey tracks the client address of the request
+func ipThrottleKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// checkThrottle refuses the login while the account or client is locked. It
+// writes the error response and returns false when refused.
+func (h *AuthHandler) checkThrottle(w http.ResponseWriter, r *http.Request, email string) bool {
+	now := time.Now()
+	for _, key := range []string{accountThrottleKey(email), ipThrottleKey(r)} {
+		throttle, err := h.throttles.Get(r.Context(), key)
+		if err != nil {
+			log.Printf("Error checking login throttle %s: %v", key, err)
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Internal Server Error")
+			return false
+		}
+		if wait := throttle.LockedUntil.Sub(now); wait > 0 {
+			seconds := int(wait.Round(time.Second).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			WriteErrorDetails(w, r, http.StatusTooManyRequests, CodeTooManyAttempts, fmt.Sprintf("Too many failed login attempts, try again in %d seconds", seconds), map[string]any{"retry_after": seconds})
+			return false
+		}
+	}
+	return true
+}
+
+// recordLoginFailure counts a failed attempt against the account and client
+func (h *AuthHandler) recordLoginFailure(ctx context.Context, r *http.Request, email string) {
+	now := time.Now()
+	failures := map[string]store.Backoff{
+		accountThrottleKey(email): backoff(accountBackoffAfter, accountLockoutAfter),
+		ipThrottleKey(r):          backoff(ipBackoffAfter, ipLockoutAfter),
+	}
+	for key, backoff := range failures {
+		throttle, err := h.throttles.RecordFailure(ctx, key, now, now.Add(-failureWindow), backoff)
+		if err != nil {
+			log.Printf("Error recording failed login for %s: %v", key, err)
+			continue
+		}
+		if throttle.Failures == accountLockoutAfter && strings.HasPrefix(key, "account:") ||
+			throttle.Failures == ipLockoutAfter {
+			log.Printf("Locked out %s after %d failed logins", key, throttle.Failures)
+		}
+	}
+}
+
+// resetLoginFailures forgets the account's failures after a successful login.
+// The client's are kept, so one valid account does not reset an attacker's count.
+func (h *AuthHandler) resetLoginFailures(ctx context.Context, email string) {
+	if err := h.throttles.Reset(ctx, accountThrottleKey(email)); err != nil {
+		log.Printf("Error resetting failed logins of %s: %v", email, err)
+	}
+}
+
+// /lockouts handlers
+
+// GetLockouts godoc
+// @Summary Get locked accounts and clients
+// @Description Lists the accounts and client addresses currently refused after failed logins
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} types.LockoutResponse
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/lockouts [get]
+func (h *AdminHandler) GetLockouts(w http.ResponseWriter, r *http.Request) {
+	stored, err := h.throttles.List(r.Context(), time.Now())
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching lockouts")
+		return
+	}
+
+	lockouts := []types.LockoutResponse{}
+	for _, throttle := range stored {
+		kind, value, _ := strings.Cut(throttle.Key, ":")
+		lockout := types.LockoutResponse{
+			Failures:    throttle.Failures,
+			LockedUntil: throttle.LockedUntil.UTC().Format(time.RFC3339),
+		}
+		if kind == "account" {
+			lockout.Email = value
+		} else {
+			lockout.IP = value
+		}
+		lockouts = append(lockouts, lockout)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(lockouts); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// Unlock godoc
+// @Summary Unlock an account or client
+// @Description Forgets the failed logins of an email or client address so logins are allowed again
+// @Tags Admin
+// @Param email query string false "Email to unlock"
+// @Param ip query string false "Client address to unlock"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/lockouts [delete]
+func (h *AdminHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	var keys []string
+	if email := router.URLQuery(r, "email"); email != "" {
+		keys = append(keys, accountThrottleKey(email))
+	}
+	if ip := router.URLQuery(r, "ip"); ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	if len(keys) == 0 {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "email or ip is required")
+		return
+	}
+
+	for _, key := range keys {
+		if err := h.throttles.Reset(r.Context(), key); err != nil {
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error unlocking")
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"go-api/store"
+	"go-api/types"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// ManagerHandler serves the /manager routes, which give managers access to
+// the reviews of the employees reporting to them
+type ManagerHandler struct {
+	employees store.EmployeeStore
+	reviews   store.ReviewStore
+	feedback  store.FeedbackStore
+	editor    reviewEditor
+}
+
+// NewManagerHandler creates a ManagerHandler using the given stores
+func NewManagerHandler(stores store.Stores) *ManagerHandler {
+	return &ManagerHandler{
+		employees: stores.Employees,
+		reviews:   stores.Reviews,
+		feedback:  stores.Feedback,
+		editor:    newReviewEditor(stores),
+	}
+}
+
+// SetEmployeeManager godoc
+// @Summary Set an employee's manager
+// @Description Makes another employee the manager of the employee, or clears the manager when manager_id is 0.
+// @Description An employee cannot end up reporting to themselves, directly or indirectly.
+// @Tags Admin
+// @Accept json
+// @Param id path int true "Employee ID"
+// @Param manager body object true "Manager ID"
+// @Param If-Match header string false "ETag of the version last read"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/employees/{id}/manager [put]
+func (h *AdminHandler) SetEmployeeManager(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := pathID(w, r, "employee")
+	if !ok {
+		return
+	}
+
+	expectedVersion, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		ManagerID int `json:"manager_id" validate:"min=0"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	version, err := h.employees.SetManager(r.Context(), employeeID, payload.ManagerID, expectedVersion)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeEmployeeNotFound, "Employee or manager not found")
+		return
+	}
+	if errors.Is(err, store.ErrVersionMismatch) {
+		writePreconditionFailed(w, r)
+		return
+	}
+	if errors.Is(err, store.ErrManagerCycle) {
+		WriteError(w, r, http.StatusConflict, CodeManagerCycle, "Manager reports to this employee")
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting manager: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting manager")
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetEmployeeReports godoc
+// @Summary Get an employee's reports
+// @Description Lists the employees reporting to the employee, directly or through other managers
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Employee ID"
+// @Param depth query int false "Levels to include, 1 for direct reports only; all levels when omitted"
+// @Success 200 {array} types.ReportResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/employees/{id}/reports [get]
+func (h *AdminHandler) GetEmployeeReports(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := pathID(w, r, "employee")
+	if !ok {
+		return
+	}
+	depth, err := parseDepth(r)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if _, err := h.employees.Get(r.Context(), employeeID); errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeEmployeeNotFound, "Employee not found")
+		return
+	} else if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching employee")
+		return
+	}
+
+	writeReports(w, r, h.employees, employeeID, depth)
+}
+
+// ListReports godoc
+// @Summary List your reports
+// @Description Lists the employees reporting to the manager, directly or through other managers
+// @Tags Manager
+// @Produce json
+// @Param depth query int false "Levels to include, 1 for direct reports only; all levels when omitted"
+// @Success 200 {array} types.ReportResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /manager/reports [get]
+func (h *ManagerHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	manager, err := employeeFromClaims(r, h.employees)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+	depth, err := parseDepth(r)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	writeReports(w, r, h.employees, manager.ID, depth)
+}
+
+// ListReviews godoc
+// @Summary List your reports' reviews
+// @Description Lists one page of the reviews of every employee reporting to the manager, with the filters and sorting of GET /admin/reviews
+// @Tags Manager
+// @Produce json
+// @Param cycle_id query int false "Only reviews in this cycle"
+// @Param status query string false "Only reviews in this status"
+// @Param employee_id query int false "Only reviews of this employee"
+// @Param reviewer_id query int false "Only reviews this employee is a reviewer of"
+// @Param created_after query string false "Only reviews created after this RFC 3339 time"
+// @Param created_before query string false "Only reviews created before this RFC 3339 time"
+// @Param has_feedback query bool false "Only reviews with (true) or without (false) submitted feedback"
+// @Param sort query string false "Comma-separated fields among id, cycle_id, employee_id, email, status and created_at, each prefixed with - for descending order, e.g. created_at,-email"
+// @Param limit query int false "Reviews per page, 1 to 200, default 50"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} types.ReviewListResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /manager/reviews [get]
+func (h *ManagerHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	manager, err := employeeFromClaims(r, h.employees)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	filter, err := parseReviewFilter(r)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	page, err := parsePage(r, store.ReviewSortFields, store.Review{})
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	filter.EmployeeIDs, err = h.reportIDs(r, manager.ID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reports")
+		return
+	}
+
+	stored, err := h.reviews.List(r.Context(), filter, page)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reviews")
+		return
+	}
+	stored, next := nextCursor(stored, page)
+	comments, err := loadComments(r.Context(), h.feedback, stored)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching feedback")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reviewList(stored, comments, next)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// AddReview godoc
+// @Summary Add a review for one of your reports
+// @Description Creates a performance review in the draft status for an employee reporting to the manager
+// @Tags Manager
+// @Accept json
+// @Produce json
+// @Param review body object true "Review info"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 201 {object} types.CreateReviewResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /manager/reviews [post]
+func (h *ManagerHandler) AddReview(w http.ResponseWriter, r *http.Request) {
+	manager, err := employeeFromClaims(r, h.employees)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	var review reviewPayload
+	if !decodePayload(w, r, &review) {
+		return
+	}
+
+	if !h.checkReport(w, r, manager.ID, review.EmployeeID) {
+		return
+	}
+	h.editor.create(w, r, review)
+}
+
+// UpdateReview godoc
+// @Summary Update a review of one of your reports
+// @Description Updates the performance review text and reviewers of a review about an employee reporting to the manager
+// @Tags Manager
+// @Accept json
+// @Param id path int true "Review ID"
+// @Param review body object true "Review info"
+// @Param If-Match header string false "ETag of the version last read"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 412 {object} types.ErrorResponse "Precondition Failed"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /manager/reviews/{id}/comments [put]
+func (h *ManagerHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	manager, err := employeeFromClaims(r, h.employees)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
+	}
+
+	var payload reviewUpdatePayload
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	review, err := h.reviews.Get(r.Context(), reviewID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewNotFound, "Review not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review")
+		return
+	}
+
+	if !h.checkReport(w, r, manager.ID, review.EmployeeID) {
+		return
+	}
+	h.editor.update(w, r, reviewID, payload)
+}
+
+// checkReport verifies that the employee reports to the manager, writing the
+// error response and returning false otherwise
+func (h *ManagerHandler) checkReport(w http.ResponseWriter, r *http.Request, managerID, employeeID int) bool {
+	reportIDs, err := h.reportIDs(r, managerID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reports")
+		return false
+	}
+	if !slices.Contains(reportIDs, employeeID) {
+		WriteError(w, r, http.StatusForbidden, CodeNotAReport, "Employee does not report to you")
+		return false
+	}
+	return true
+}
+
+// reportIDs returns the IDs of every direct and indirect report of the manager
+func (h *ManagerHandler) reportIDs(r *http.Request, managerID int) ([]int, error) {
+	reports, err := h.employees.Reports(r.Context(), managerID, 0)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(reports))
+	for _, report := range reports {
+		ids = append(ids, report.ID)
+	}
+	return ids, nil
+}
+
+// writeReports responds with the reports of the manager down to depth levels
+func writeReports(w http.ResponseWriter, r *http.Request, employees store.EmployeeStore, managerID, depth int) {
+	stored, err := employees.Reports(r.Context(), managerID, depth)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reports")
+		return
+	}
+
+	reports := []types.ReportResponse{}
+	for _, report := range stored {
+		reports = append(reports, types.ReportResponse{
+			ID:        report.ID,
+			Email:     report.Email,
+			Position:  report.Position,
+			ManagerID: report.ManagerID,
+			Depth:     report.Depth,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// parseDepth reads the optional depth query parameter; zero means every level
+func parseDepth(r *http.Request) (int, error) {
+	value := router.URLQuery(r, "depth")
+	if value == "" {
+		return 0, nil
+	}
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 {
+		return 0, fmt.Errorf("invalid depth %q, must be a positive number", value)
+	}
+	return depth, nil
+}
@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-api/store"
+	"go-api/totp"
+	"go-api/types"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// mfaChallengeTTL is how long a user has to enter their second factor after the password
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaChallengePurpose marks challenge tokens so they cannot be mistaken for other tokens
+	mfaChallengePurpose = "mfa_challenge"
+	// totpIssuer names this API in authenticator apps
+	totpIssuer = "Employee Reviews"
+	// recoveryCodeCount is how many recovery codes each enrolment gets
+	recoveryCodeCount = 10
+)
+
+// Stages of a login challenge
+const (
+	// mfaStageVerify asks for a code of an enrolled authenticator
+	mfaStageVerify = "verify"
+	// mfaStageEnroll asks a user whose role requires two-factor authentication to enrol
+	mfaStageEnroll = "enroll"
+)
+
+// errInvalidCode is returned for wrong, replayed or used second factor codes
+var errInvalidCode = errors.New("invalid code")
+
+// mfaChallengeClaims is signed into the mfa_token login returns after the password
+type mfaChallengeClaims struct {
+	Purpose string `json:"purpose"`
+	UserID  int    `json:"uid"`
+	Stage   string `json:"stage"`
+	jwt.StandardClaims
+}
+
+// completeLogin finishes a login whose first factor was checked, asking for
+// the second factor when the user has one or their role requires one
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, user store.User) {
+	enrolment, err := h.mfa.GetTOTP(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error loading two-factor enrolment of user %d: %v", user.ID, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return
+	}
+
+	stage := ""
+	if enrolment.Enabled {
+		stage = mfaStageVerify
+	} else {
+		required, err := h.mfaRequired(r.Context(), user)
+		if err != nil {
+			log.Printf("Error checking two-factor requirement of user %d: %v", user.ID, err)
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+			return
+		}
+		if required {
+			stage = mfaStageEnroll
+		}
+	}
+	if stage == "" {
+		h.startSession(w, r, user)
+		return
+	}
+
+	challenge, err := h.keys.Sign(&mfaChallengeClaims{
+		Purpose: mfaChallengePurpose,
+		UserID:  user.ID,
+		Stage:   stage,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(mfaChallengeTTL).Unix(),
+		},
+	})
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.MFAChallengeResponse{
+		MFARequired:        stage == mfaStageVerify,
+		EnrollmentRequired: stage == mfaStageEnroll,
+		MFAToken:           challenge,
+		ExpiresIn:          int(mfaChallengeTTL.Seconds()),
+	}); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// mfaRequired reports whether the user's stored or effective role requires two-factor authentication
+func (h *AuthHandler) mfaRequired(ctx context.Context, user store.User) (bool, error) {
+	effective, err := h.effectiveRole(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range []string{user.Role, effective} {
+		role, err := h.roles.Get(ctx, name)
+		if err != nil {
+			return false, err
+		}
+		if role.MFARequired {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// challengeUser verifies an mfa_token and loads its user, returning the stage
+// of the challenge. It writes the error response and returns false on failure.
+func (h *AuthHandler) challengeUser(w http.ResponseWriter, r *http.Request, mfaToken string) (store.User, string, bool) {
+	var claims mfaChallengeClaims
+	token, err := h.keys.Parse(mfaToken, &claims)
+	if err != nil || !token.Valid || claims.Purpose != mfaChallengePurpose {
+		WriteError(w, r, http.StatusUnauthorized, CodeMFATokenInvalid, "Invalid or expired mfa_token, log in again")
+		return store.User{}, "", false
+	}
+
+	user, err := h.users.Get(r.Context(), claims.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusUnauthorized, CodeMFATokenInvalid, "Invalid or expired mfa_token, log in again")
+		return store.User{}, "", false
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return store.User{}, "", false
+	}
+	return user, claims.Stage, true
+}
+
+// LoginMFA godoc
+// @Summary Finish logging in with a second factor
+// @Description Exchanges the mfa_token from /login and an authenticator or recovery code for tokens.
+// @Description When enrolment was required, the code confirms the authenticator set up through /login/mfa/enroll and the response includes recovery codes.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object true "mfa_token and code"
+// @Success 200 {object} types.TokenResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 429 {object} types.ErrorResponse "Too Many Requests"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /login/mfa [post]
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		MFAToken string `json:"mfa_token" validate:"required"`
+		Code     string `json:"code" validate:"required,max=64"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	user, stage, ok := h.challengeUser(w, r, payload.MFAToken)
+	if !ok {
+		return
+	}
+	// Codes are guessed far more easily than passwords, so they count against the same limits
+	if !h.checkThrottle(w, r, user.Email) {
+		return
+	}
+
+	if stage == mfaStageEnroll {
+		recoveryCodes, ok := h.confirmTOTP(w, r, user.ID, payload.Code)
+		if !ok {
+			return
+		}
+		h.startSession(w, r, user, recoveryCodes...)
+		return
+	}
+
+	if !h.checkSecondFactor(w, r, user.ID, payload.Code) {
+		h.recordLoginFailure(r.Context(), r, user.Email)
+		return
+	}
+	h.resetLoginFailures(r.Context(), user.Email)
+	h.startSession(w, r, user)
+}
+
+// LoginMFAEnroll godoc
+// @Summary Set up an authenticator while logging in
+// @Description For users whose role requires two-factor authentication but who have not enrolled yet: exchanges the mfa_token from /login for a new authenticator secret. Confirm it with a code at /login/mfa.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object true "mfa_token"
+// @Success 200 {object} types.TOTPEnrollmentResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /login/mfa/enroll [post]
+func (h *AuthHandler) LoginMFAEnroll(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		MFAToken string `json:"mfa_token" validate:"required"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+	user, stage, ok := h.challengeUser(w, r, payload.MFAToken)
+	if !ok {
+		return
+	}
+	if stage != mfaStageEnroll {
+		WriteError(w, r, http.StatusConflict, CodeMFAAlreadyEnabled, "Two-factor authentication is already set up")
+		return
+	}
+	h.beginTOTP(w, r, user)
+}
+
+// StartTOTP godoc
+// @Summary Set up an authenticator
+// @Description Creates a new authenticator secret for the current user. Two-factor authentication is enabled once a code is confirmed at /me/mfa/totp/confirm.
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.TOTPEnrollmentResponse
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /me/mfa/totp [post]
+func (h *AuthHandler) StartTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	enrolment, err := h.mfa.GetTOTP(r.Context(), user.ID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting up two-factor authentication")
+		return
+	}
+	if enrolment.Enabled {
+		WriteError(w, r, http.StatusConflict, CodeMFAAlreadyEnabled, "Two-factor authentication is already enabled; disable it first")
+		return
+	}
+	h.beginTOTP(w, r, user)
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm an authenticator
+// @Description Enables two-factor authentication with a code from the authenticator set up at /me/mfa/totp and returns recovery codes, which are only shown once.
+// @Tags Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body object true "code"
+// @Success 200 {object} types.RecoveryCodesResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /me/mfa/totp/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Code string `json:"code" validate:"required,max=64"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	recoveryCodes, ok := h.confirmTOTP(w, r, user.ID, payload.Code)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// DisableTOTP godoc
+// @Summary Turn off two-factor authentication
+// @Description Removes the authenticator and recovery codes after checking a code from either. Not allowed when the user's role requires two-factor authentication.
+// @Tags Authentication
+// @Security BearerAuth
+// @Accept json
+// @Param request body object true "code"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /me/mfa/totp [delete]
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Code string `json:"code" validate:"required,max=64"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	required, err := h.mfaRequired(r.Context(), user)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error disabling two-factor authentication")
+		return
+	}
+	if required {
+		WriteError(w, r, http.StatusConflict, CodeMFARequiredByRole, "Your role requires two-factor authentication")
+		return
+	}
+	if !h.checkSecondFactor(w, r, user.ID, payload.Code) {
+		return
+	}
+
+	if err := h.mfa.DisableTOTP(r.Context(), user.ID); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error disabling two-factor authentication")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// currentUser loads the account of the authenticated request. It writes the
+// error response and returns false on failure.
+func (h *AuthHandler) currentUser(w http.ResponseWriter, r *http.Request) (store.User, bool) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return store.User{}, false
+	}
+	user, err := h.users.Get(r.Context(), claims.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return store.User{}, false
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error loading account")
+		return store.User{}, false
+	}
+	return user, true
+}
+
+// beginTOTP stores a new pending secret for the user and responds with it
+func (h *AuthHandler) beginTOTP(w http.ResponseWriter, r *http.Request, user store.User) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting up two-factor authentication")
+		return
+	}
+	if err := h.mfa.StartTOTP(r.Context(), user.ID, secret); err != nil {
+		log.Printf("Error storing authenticator secret of user %d: %v", user.ID, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting up two-factor authentication")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.TOTPEnrollmentResponse{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Email, secret),
+	}); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// confirmTOTP enables the user's pending secret when the code matches it and
+// returns new recovery codes. It writes the error response and returns false on failure.
+func (h *AuthHandler) confirmTOTP(w http.ResponseWriter, r *http.Request, userID int, code string) ([]string, bool) {
+	enrolment, err := h.mfa.GetTOTP(r.Context(), userID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting up two-factor authentication")
+		return nil, false
+	}
+	if enrolment.Enabled || enrolment.Secret == "" {
+		WriteError(w, r, http.StatusConflict, CodeMFANotPending, "No authenticator is waiting to be confirmed")
+		return nil, false
+	}
+	step, ok := totp.Validate(enrolment.Secret, code, time.Now())
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, CodeMFACodeInvalid, "Invalid code")
+		return nil, false
+	}
+
+	recoveryCodes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range recoveryCodes {
+		if recoveryCodes[i], err = newRecoveryCode(); err != nil {
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting up two-factor authentication")
+			return nil, false
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(recoveryCodes[i]))
+	}
+	if err := h.mfa.EnableTOTP(r.Context(), userID, step, hashes); err != nil {
+		log.Printf("Error enabling two-factor authentication of user %d: %v", userID, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting up two-factor authentication")
+		return nil, false
+	}
+	return recoveryCodes, true
+}
+
+// checkSecondFactor accepts a current authenticator code or an unused recovery
+// code of the user. It writes the error response and returns false on failure.
+func (h *AuthHandler) checkSecondFactor(w http.ResponseWriter, r *http.Request, userID int, code string) bool {
+	err := h.useSecondFactor(r.Context(), userID, code)
+	if errors.Is(err, errInvalidCode) {
+		WriteError(w, r, http.StatusUnauthorized, CodeMFACodeInvalid, "Invalid code")
+		return false
+	}
+	if err != nil {
+		log.Printf("Error checking second factor of user %d: %v", userID, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error checking code")
+		return false
+	}
+	return true
+}
+
+// useSecondFactor uses up the code, returning errInvalidCode when it does not match
+func (h *AuthHandler) useSecondFactor(ctx context.Context, userID int, code string) error {
+	enrolment, err := h.mfa.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enrolment.Enabled {
+		return errInvalidCode
+	}
+
+	if step, ok := totp.Validate(enrolment.Secret, code, time.Now()); ok {
+		err := h.mfa.UseTOTPStep(ctx, userID, step)
+		if errors.Is(err, store.ErrCodeUsed) {
+			return errInvalidCode
+		}
+		return err
+	}
+
+	err = h.mfa.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, store.ErrNotFound) {
+		return errInvalidCode
+	}
+	return err
+}
+
+// newRecoveryCode returns a random code formatted as XXXXX-XXXXX
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := base32.StdEncoding.EncodeToString(b)[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes when recovery codes are typed in
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
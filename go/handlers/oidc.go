@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"go-api/oidc"
+	"go-api/store"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// oidcFlowCookie carries the state of a sign-in between the login and callback routes
+const oidcFlowCookie = "oidc_flow"
+
+// oidcFlowTTL is how long a user has to sign in at the identity provider
+const oidcFlowTTL = 10 * time.Minute
+
+// OIDCHandler serves single sign-on through an OpenID Connect provider
+type OIDCHandler struct {
+	auth     *AuthHandler
+	provider *oidc.Provider
+	// provision creates an employee for unknown emails instead of refusing them
+	provision bool
+}
+
+// NewOIDCHandler creates an OIDCHandler issuing tokens through auth
+func NewOIDCHandler(auth *AuthHandler, provider *oidc.Provider, provision bool) *OIDCHandler {
+	return &OIDCHandler{auth: auth, provider: provider, provision: provision}
+}
+
+// oidcFlowClaims is signed into the flow cookie so the callback can trust it
+type oidcFlowClaims struct {
+	Purpose      string `json:"purpose"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	jwt.StandardClaims
+}
+
+// Login godoc
+// @Summary Sign in with the identity provider
+// @Description Redirects the browser to the OpenID Connect provider to sign in; it returns to /auth/oidc/callback
+// @Tags Authentication
+// @Success 302 {string} string "Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var flow oidcFlowClaims
+	var err error
+	for _, value := range []*string{&flow.State, &flow.Nonce, &flow.CodeVerifier} {
+		if *value, err = randomToken(32); err != nil {
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not start sign-in")
+			return
+		}
+	}
+	flow.Purpose = oidcFlowCookie
+	flow.ExpiresAt = time.Now().Add(oidcFlowTTL).Unix()
+
+	cookie, err := h.auth.keys.Sign(&flow)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not start sign-in")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    cookie,
+		Path:     "/auth/oidc",
+		MaxAge:   int(oidcFlowTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		// Lax still sends the cookie on the provider's top-level redirect back
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, h.provider.AuthCodeURL(flow.State, flow.Nonce, flow.CodeVerifier), http.StatusFound)
+}
+
+// Callback godoc
+// @Summary Finish signing in with the identity provider
+// @Description Exchanges the authorization code, maps the verified email onto a user and returns the same tokens as /login
+// @Tags Authentication
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the login redirect"
+// @Success 200 {object} types.TokenResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeSSOExpired, "Sign-in expired, start again")
+		return
+	}
+	// The flow can only be finished once
+	http.SetCookie(w, &http.Cookie{Name: oidcFlowCookie, Path: "/auth/oidc", MaxAge: -1, HttpOnly: true})
+
+	var flow oidcFlowClaims
+	token, err := h.auth.keys.Parse(cookie.Value, &flow)
+	if err != nil || !token.Valid || flow.Purpose != oidcFlowCookie {
+		WriteError(w, r, http.StatusBadRequest, CodeSSOExpired, "Sign-in expired, start again")
+		return
+	}
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		WriteError(w, r, http.StatusUnauthorized, CodeSSOFailed, "Sign-in failed: "+providerError)
+		return
+	}
+	if query.Get("state") != flow.State || query.Get("code") == "" {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "Invalid sign-in response")
+		return
+	}
+
+	rawIDToken, err := h.provider.Exchange(r.Context(), query.Get("code"), flow.CodeVerifier)
+	if err != nil {
+		log.Printf("Error exchanging authorization code: %v", err)
+		WriteError(w, r, http.StatusUnauthorized, CodeSSOFailed, "Sign-in failed")
+		return
+	}
+	idToken, err := h.provider.Verify(r.Context(), rawIDToken, flow.Nonce)
+	if err != nil {
+		log.Printf("Error verifying ID token: %v", err)
+		WriteError(w, r, http.StatusUnauthorized, CodeSSOFailed, "Sign-in failed")
+		return
+	}
+	if idToken.Email == "" || (idToken.EmailVerified != nil && !*idToken.EmailVerified) {
+		WriteError(w, r, http.StatusUnauthorized, CodeSSOFailed, "Sign-in failed: the identity provider did not return a verified email")
+		return
+	}
+
+	user, err := h.auth.users.GetByEmail(r.Context(), idToken.Email)
+	if errors.Is(err, store.ErrNotFound) && h.provision {
+		user, err = h.provisionUser(r, idToken.Email)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusUnauthorized, CodeSSOAccountNotFound, "No account for "+idToken.Email)
+		return
+	}
+	if err != nil {
+		log.Printf("Error signing in %s: %v", idToken.Email, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Sign-in failed")
+		return
+	}
+
+	// Users who set up an authenticator still need it, as with password logins
+	h.auth.completeLogin(w, r, user)
+}
+
+// provisionUser creates an employee for a first-time single sign-on user. The
+// account has no password, so it can only sign in through the provider.
+func (h *OIDCHandler) provisionUser(r *http.Request, email string) (store.User, error) {
+	if _, err := h.auth.employees.Create(r.Context(), store.Employee{Email: email}, ""); err != nil {
+		return store.User{}, err
+	}
+	log.Printf("Provisioned employee %s on first sign-in", email)
+	return h.auth.users.GetByEmail(r.Context(), email)
+}
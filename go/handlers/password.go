@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go-api/mail"
+	"go-api/passwords"
+	"go-api/store"
+	"go-api/validate"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordResetTTL is how long a password reset link can be used
+const PasswordResetTTL = time.Hour
+
+// InviteTTL is how long the link sent to an invited employee can be used
+const InviteTTL = 7 * 24 * time.Hour
+
+// PasswordHandler serves password changes and resets
+type PasswordHandler struct {
+	auth   *AuthHandler
+	resets store.PasswordResetStore
+	policy *passwords.Policy
+	mailer mail.Sender
+	// resetURL is the page reset links point at; the token is added as ?token=
+	resetURL string
+}
+
+// NewPasswordHandler creates a PasswordHandler using the given stores, issuing tokens through auth
+func NewPasswordHandler(stores store.Stores, auth *AuthHandler, policy *passwords.Policy, mailer mail.Sender, resetURL string) *PasswordHandler {
+	return &PasswordHandler{auth: auth, resets: stores.Resets, policy: policy, mailer: mailer, resetURL: resetURL}
+}
+
+// ChangePassword godoc
+// @Summary Change your password
+// @Description Sets a new password after checking the current one. Every other session is logged out and new tokens are returned.
+// @Tags Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body object true "current_password and new_password"
+// @Success 200 {object} types.TokenResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /me/password [post]
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var payload struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	user, err := h.auth.users.Get(r.Context(), claims.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error changing password")
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(payload.CurrentPassword)) != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodePasswordIncorrect, "Current password is incorrect")
+		return
+	}
+
+	if !h.setPassword(w, r, user.ID, payload.NewPassword) {
+		return
+	}
+	h.auth.startSession(w, r, user)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Emails a single-use password reset link if an account uses the email. The response is the same either way.
+// @Tags Authentication
+// @Accept json
+// @Param request body object true "email"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 202 {string} string "Accepted"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Router /password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Email string `json:"email" validate:"required,email,max=254"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	// Failures are only logged so the response does not reveal which emails have accounts
+	if err := h.sendReset(r, payload.Email); err != nil {
+		log.Printf("Error sending password reset to %s: %v", payload.Email, err)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendReset stores a reset token for the account with the email, if any, and mails it
+func (h *PasswordHandler) sendReset(r *http.Request, email string) error {
+	user, err := h.auth.users.GetByEmail(r.Context(), email)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	instructions, err := h.passwordLink(r.Context(), user, PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(r.Context(), mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account. %s\n\n"+
+			"It works once within %d minutes. If it was not you, ignore this email.", instructions, int(PasswordResetTTL.Minutes())),
+	})
+}
+
+// invite mails the new employee with the email a link to choose their first password
+func (h *PasswordHandler) invite(ctx context.Context, email string) error {
+	user, err := h.auth.users.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	instructions, err := h.passwordLink(ctx, user, InviteTTL)
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Choose your password",
+		Body: fmt.Sprintf("An account was created for you. %s\n\n"+
+			"It works once within %d days.", instructions, int(InviteTTL.Hours()/24)),
+	})
+}
+
+// passwordLink stores a single-use reset token for the user, valid for ttl,
+// and returns the instructions for using it to put in an email
+func (h *PasswordHandler) passwordLink(ctx context.Context, user store.User, ttl time.Duration) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = h.resets.Create(ctx, store.PasswordReset{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if h.resetURL != "" {
+		return fmt.Sprintf("Open this link to choose a new password:\n\n%s?token=%s", h.resetURL, url.QueryEscape(token)), nil
+	}
+	return fmt.Sprintf("To choose a new password, send this to POST /password/reset:\n\n"+
+		"{\"token\": %q, \"new_password\": \"...\"}", token), nil
+}
+
+// ResetPassword godoc
+// @Summary Reset a forgotten password
+// @Description Sets a new password using the token from a reset email. The token works once, and every session of the account is logged out.
+// @Tags Authentication
+// @Accept json
+// @Param request body object true "token and new_password"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /password/reset [post]
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token       string `json:"token" validate:"required"`
+		NewPassword string `json:"new_password" validate:"required"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+	// Check the policy first so a rejected password does not use up the token
+	if err := h.policy.Check(payload.NewPassword); err != nil {
+		writeValidationErrors(w, r, validate.Errors{"new_password": err.Error()})
+		return
+	}
+
+	reset, err := h.resets.Consume(r.Context(), hashToken(payload.Token))
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusBadRequest, CodeResetTokenInvalid, "Reset link is invalid or has expired")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error resetting password")
+		return
+	}
+
+	if !h.setPassword(w, r, reset.UserID, payload.NewPassword) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setPassword checks the new_password against the policy, stores it and logs out
+// every session of the user. It writes the error response and returns false on failure.
+func (h *PasswordHandler) setPassword(w http.ResponseWriter, r *http.Request, userID int, password string) bool {
+	if err := h.policy.Check(password); err != nil {
+		writeValidationErrors(w, r, validate.Errors{"new_password": err.Error()})
+		return false
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error hashing password")
+		return false
+	}
+	if err := h.auth.users.SetPassword(r.Context(), userID, hashedPassword); err != nil {
+		log.Printf("Error setting password of user %d: %v", userID, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting password")
+		return false
+	}
+	if err := h.auth.tokens.RevokeUser(r.Context(), userID); err != nil {
+		log.Printf("Error revoking sessions of user %d: %v", userID, err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting password")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"go-api/validate"
+)
+
+// decodeMergePatch applies the JSON Merge Patch (RFC 7396) in the request body
+// to payload, which holds the record's current values, and checks the result
+// against its validate tags. Fields the patch leaves out keep their value and
+// null removes one, which fails a required rule. It writes a 400 or 422 and
+// returns false when the patch is refused.
+func decodeMergePatch(w http.ResponseWriter, r *http.Request, payload any) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	var patch map[string]any
+	if err := decoder.Decode(&patch); err != nil || patch == nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload, expected a JSON object")
+		return false
+	}
+
+	current, err := json.Marshal(payload)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error applying patch")
+		return false
+	}
+	var document any
+	decoder = json.NewDecoder(bytes.NewReader(current))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error applying patch")
+		return false
+	}
+	patched, err := json.Marshal(mergePatch(document, patch))
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error applying patch")
+		return false
+	}
+
+	// Start from zero values so that removed fields do not keep their old value
+	target := reflect.ValueOf(payload).Elem()
+	target.SetZero()
+	if err := json.Unmarshal(patched, payload); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload")
+		return false
+	}
+	if errs := validate.Struct(payload); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return false
+	}
+	return true
+}
+
+// mergePatch returns target with patch applied as RFC 7396 describes: objects
+// are merged member by member, null removes a member and anything else
+// replaces the value it patches
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
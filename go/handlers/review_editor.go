@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"go-api/store"
+	"go-api/types"
+	"go-api/validate"
+)
+
+// reviewPayload is the request body for creating reviews
+type reviewPayload struct {
+	CycleID           int    `json:"cycle_id" validate:"required,min=1"`      // Cycle the review belongs to
+	TemplateID        int    `json:"template_id" validate:"min=0"`            // Optional template the feedback follows
+	EmployeeID        int    `json:"employee_id" validate:"required,min=1"`   // Employee being reviewed
+	PerformanceReview string `json:"performance_review" validate:"max=10000"` // Review text
+	ReviewerIDs       []int  `json:"reviewer_ids" validate:"unique,max=50"`   // List of reviewers
+}
+
+// reviewUpdatePayload is the request body for updating reviews
+type reviewUpdatePayload struct {
+	PerformanceReview string `json:"performance_review" validate:"max=10000"` // Updated review text
+	ReviewerIDs       []int  `json:"reviewer_ids" validate:"unique,max=50"`   // List of new reviewers
+}
+
+// reviewEditor creates and updates reviews for both admins and managers, who
+// differ only in which employees they may review
+type reviewEditor struct {
+	employees store.EmployeeStore
+	reviews   store.ReviewStore
+	cycles    store.CycleStore
+	templates store.TemplateStore
+}
+
+func newReviewEditor(stores store.Stores) reviewEditor {
+	return reviewEditor{
+		employees: stores.Employees,
+		reviews:   stores.Reviews,
+		cycles:    stores.Cycles,
+		templates: stores.Templates,
+	}
+}
+
+// create adds the review and writes the response
+func (e reviewEditor) create(w http.ResponseWriter, r *http.Request, review reviewPayload) {
+	if !e.checkCycle(w, r, review.CycleID, review.TemplateID) {
+		return
+	}
+
+	if !e.checkPeople(w, r, review.EmployeeID, review.ReviewerIDs) {
+		return
+	}
+
+	reviewID, err := e.reviews.Create(r.Context(), store.Review{
+		CycleID:           review.CycleID,
+		TemplateID:        review.TemplateID,
+		EmployeeID:        review.EmployeeID,
+		PerformanceReview: review.PerformanceReview,
+		ReviewerIDs:       review.ReviewerIDs,
+	})
+	if writeConstraintError(w, r, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error adding review: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error adding review")
+		return
+	}
+
+	// Respond with the review ID
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(types.CreateReviewResponse{
+		ReviewID: reviewID,
+	})
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error encoding response for add review")
+	}
+}
+
+// update replaces the review text and reviewers and writes the response
+func (e reviewEditor) update(w http.ResponseWriter, r *http.Request, reviewID int, payload reviewUpdatePayload) {
+	review, expectedVersion, ok := e.editable(w, r, reviewID)
+	if !ok {
+		return
+	}
+	e.save(w, r, review, payload, expectedVersion)
+}
+
+// patch applies the merge patch in the request body to the review text and
+// reviewers and writes the response
+func (e reviewEditor) patch(w http.ResponseWriter, r *http.Request, reviewID int) {
+	review, _, ok := e.editable(w, r, reviewID)
+	if !ok {
+		return
+	}
+
+	payload := reviewUpdatePayload{PerformanceReview: review.PerformanceReview, ReviewerIDs: review.ReviewerIDs}
+	if !decodeMergePatch(w, r, &payload) {
+		return
+	}
+	// The patch applies to the version just read, so a concurrent change fails it
+	e.save(w, r, review, payload, review.Version)
+}
+
+// addReviewer assigns one more reviewer and writes the response
+func (e reviewEditor) addReviewer(w http.ResponseWriter, r *http.Request, reviewID, reviewerID int) {
+	review, expectedVersion, ok := e.editable(w, r, reviewID)
+	if !ok {
+		return
+	}
+	if slices.Contains(review.ReviewerIDs, reviewerID) {
+		WriteError(w, r, http.StatusConflict, CodeConflict, "Employee already reviews this review")
+		return
+	}
+	errs := validate.Errors{}
+	if reviewerID == review.EmployeeID {
+		errs.Add("reviewer_id", "must not be the employee being reviewed")
+	} else if !e.checkEmployee(w, r, errs, "reviewer_id", reviewerID) {
+		return
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	version, err := e.reviews.AddReviewer(r.Context(), reviewID, reviewerID, expectedVersion)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewNotFound, "Review not found")
+		return
+	}
+	if errors.Is(err, store.ErrVersionMismatch) {
+		writePreconditionFailed(w, r)
+		return
+	}
+	if writeConstraintError(w, r, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error adding reviewer: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error adding reviewer")
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeReviewer unassigns one reviewer and writes the response
+func (e reviewEditor) removeReviewer(w http.ResponseWriter, r *http.Request, reviewID, reviewerID int) {
+	_, expectedVersion, ok := e.editable(w, r, reviewID)
+	if !ok {
+		return
+	}
+
+	version, err := e.reviews.RemoveReviewer(r.Context(), reviewID, reviewerID, expectedVersion)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewNotFound, "Review not found")
+		return
+	}
+	if errors.Is(err, store.ErrNotAReviewer) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewerNotFound, "Employee does not review this review")
+		return
+	}
+	if errors.Is(err, store.ErrVersionMismatch) {
+		writePreconditionFailed(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Error removing reviewer: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error removing reviewer")
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// editable fetches the review along with the version the If-Match header
+// expects, writing a 404, 409 or 412 and returning false unless it exists, has
+// not been submitted for sign-off and still has that version
+func (e reviewEditor) editable(w http.ResponseWriter, r *http.Request, reviewID int) (store.Review, int, bool) {
+	expectedVersion, ok := ifMatch(w, r)
+	if !ok {
+		return store.Review{}, 0, false
+	}
+
+	review, err := e.reviews.Get(r.Context(), reviewID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewNotFound, "Review not found")
+		return review, 0, false
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review")
+		return review, 0, false
+	}
+	if expectedVersion != 0 && review.Version != expectedVersion {
+		writePreconditionFailed(w, r)
+		return review, 0, false
+	}
+	if !store.ReviewEditable(review.Status) {
+		WriteError(w, r, http.StatusConflict, CodeReviewLocked, "Review can no longer be edited")
+		return review, 0, false
+	}
+	return review, expectedVersion, true
+}
+
+// save stores the new text and reviewers of the review and writes the response
+// with the ETag of the new version
+func (e reviewEditor) save(w http.ResponseWriter, r *http.Request, review store.Review, payload reviewUpdatePayload, expectedVersion int) {
+	if !e.checkPeople(w, r, review.EmployeeID, payload.ReviewerIDs) {
+		return
+	}
+
+	version, err := e.reviews.Update(r.Context(), review.ID, payload.PerformanceReview, payload.ReviewerIDs, expectedVersion)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewNotFound, "Review not found")
+		return
+	}
+	if errors.Is(err, store.ErrVersionMismatch) {
+		writePreconditionFailed(w, r)
+		return
+	}
+	if writeConstraintError(w, r, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating review: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error updating review")
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkCycle checks that the cycle exists and still accepts reviews and that
+// the template, if any, exists, writing a 400, 409 or 500 and returning false otherwise
+func (e reviewEditor) checkCycle(w http.ResponseWriter, r *http.Request, cycleID, templateID int) bool {
+	// Reviews can only be added to cycles that still accept feedback
+	cycle, err := e.cycles.Get(r.Context(), cycleID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusBadRequest, CodeCycleNotFound, "Review cycle not found")
+		return false
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review cycle")
+		return false
+	}
+	if cycle.IsClosed(time.Now()) {
+		WriteError(w, r, http.StatusConflict, CodeCycleClosed, "Review cycle is closed")
+		return false
+	}
+
+	if templateID != 0 {
+		_, err := e.templates.Get(r.Context(), templateID)
+		if errors.Is(err, store.ErrNotFound) {
+			WriteError(w, r, http.StatusBadRequest, CodeTemplateNotFound, "Review template not found")
+			return false
+		}
+		if err != nil {
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review template")
+			return false
+		}
+	}
+	return true
+}
+
+// checkPeople checks that the reviewed employee and every reviewer exist and
+// that nobody reviews themselves, writing a 422 and returning false otherwise
+func (e reviewEditor) checkPeople(w http.ResponseWriter, r *http.Request, employeeID int, reviewerIDs []int) bool {
+	errs := validate.Errors{}
+	if !e.checkEmployee(w, r, errs, "employee_id", employeeID) {
+		return false
+	}
+	for i, reviewerID := range reviewerIDs {
+		field := fmt.Sprintf("reviewer_ids[%d]", i)
+		if reviewerID == employeeID {
+			errs.Add(field, "must not be the employee being reviewed")
+			continue
+		}
+		if !e.checkEmployee(w, r, errs, field, reviewerID) {
+			return false
+		}
+	}
+
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return false
+	}
+	return true
+}
+
+// checkEmployee records in errs when the employee given in field does not
+// exist. It writes a 500 and returns false when the lookup fails.
+func (e reviewEditor) checkEmployee(w http.ResponseWriter, r *http.Request, errs validate.Errors, field string, id int) bool {
+	_, err := e.employees.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		errs.Add(field, fmt.Sprintf("employee %d does not exist", id))
+		return true
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching employee")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	"go-api/store"
+	"go-api/types"
+	"go-api/validate"
+)
+
+// maxJobReviews bounds the employees one review job reviews
+const maxJobReviews = 1000
+
+// maxJobReviewers bounds the reviewers of each review, as for a single review
+const maxJobReviewers = 50
+
+// reviewJobPayload is the request body for creating reviews in bulk
+type reviewJobPayload struct {
+	CycleID    int                `json:"cycle_id" validate:"required,min=1"`                            // Cycle the reviews belong to
+	TemplateID int                `json:"template_id" validate:"min=0"`                                  // Optional template the feedback follows
+	Strategy   string             `json:"strategy" validate:"required,oneof=manager_peers team mapping"` // How reviewers are chosen
+	Peers      int                `json:"peers" validate:"min=0,max=49"`                                 // Random peers per review with manager_peers
+	Filter     reviewJobFilter    `json:"filter"`                                                        // Employees to review, unless mapped
+	Mapping    []reviewJobMapping `json:"mapping" validate:"max=1000"`                                   // Employees and their reviewers with mapping
+}
+
+// reviewJobFilter selects the employees a job reviews; empty fields match everyone
+type reviewJobFilter struct {
+	Position    string `json:"position" validate:"max=100"`             // Only employees with this position
+	ManagerID   int    `json:"manager_id" validate:"min=0"`             // Only the manager's direct reports
+	Indirect    bool   `json:"indirect"`                                // Also the manager's indirect reports
+	EmployeeIDs []int  `json:"employee_ids" validate:"unique,max=1000"` // Only these employees
+}
+
+// empty reports whether the filter matches every employee
+func (f reviewJobFilter) empty() bool {
+	return f.Position == "" && f.ManagerID == 0 && !f.Indirect && len(f.EmployeeIDs) == 0
+}
+
+// reviewJobMapping lists the reviewers of one employee for the mapping strategy
+type reviewJobMapping struct {
+	EmployeeID  int   `json:"employee_id" validate:"required,min=1"`
+	ReviewerIDs []int `json:"reviewer_ids" validate:"unique,max=50"`
+}
+
+// AddReviewJob godoc
+// @Summary Create reviews in bulk
+// @Description Creates a draft review in a cycle for every employee matching the filter, or every mapped employee, and records them as a job. Reviewers are chosen by strategy: manager_peers assigns the employee's manager and the given number of random peers reporting to the same manager, team assigns the manager and all of those peers, and mapping assigns the reviewers listed for each employee. With manager_peers and team, employees who have no manager are skipped. Employees who already have a review in the cycle are skipped too. All reviews are created in one transaction.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param job body object true "Cycle, strategy and employees to review"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 201 {object} types.ReviewJobResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/review-jobs [post]
+func (h *AdminHandler) AddReviewJob(w http.ResponseWriter, r *http.Request) {
+	var payload reviewJobPayload
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+	if !h.editor.checkCycle(w, r, payload.CycleID, payload.TemplateID) {
+		return
+	}
+
+	stored, err := h.employees.List(r.Context(), store.EmployeeFilter{}, store.Page{})
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching employees")
+		return
+	}
+	employees := map[int]store.Employee{}
+	for _, employee := range stored {
+		employees[employee.ID] = employee
+	}
+
+	errs := validate.Errors{}
+	var results []store.ReviewJobResult
+	if payload.Strategy == store.StrategyMapping {
+		results = mappedReviewers(errs, payload, employees)
+	} else {
+		results, err = h.selectForJob(r, errs, payload, employees)
+		if err != nil {
+			WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reports")
+			return
+		}
+	}
+	if len(errs) == 0 && len(results) == 0 {
+		errs.Add("filter", "matches no employees")
+	}
+	if len(results) > maxJobReviews {
+		errs.Add("filter", fmt.Sprintf("matches more than %d employees", maxJobReviews))
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	if payload.Strategy != store.StrategyMapping {
+		assignReviewers(results, payload.Strategy, payload.Peers, stored)
+	}
+	if !h.skipReviewed(w, r, payload.CycleID, results) {
+		return
+	}
+
+	job := store.ReviewJob{
+		CycleID:    payload.CycleID,
+		TemplateID: payload.TemplateID,
+		Strategy:   payload.Strategy,
+		Results:    results,
+	}
+	if claims, ok := ClaimsFromContext(r.Context()); ok {
+		job.CreatedBy = claims.ID
+	}
+	job, err = h.jobs.Create(r.Context(), job)
+	if writeConstraintError(w, r, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating review job: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error creating reviews")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(reviewJobResponse(job)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// GetReviewJob godoc
+// @Summary Get a review job
+// @Description Fetches the summary of a review job: the review created for each employee and why others were skipped
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Review job ID"
+// @Success 200 {object} types.ReviewJobResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/review-jobs/{id} [get]
+func (h *AdminHandler) GetReviewJob(w http.ResponseWriter, r *http.Request) {
+	jobID, ok := pathID(w, r, "review job")
+	if !ok {
+		return
+	}
+
+	job, err := h.jobs.Get(r.Context(), jobID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewJobNotFound, "Review job not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reviewJobResponse(job)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// mappedReviewers turns the mapping into results, recording in errs unknown
+// employees, employees mapped twice and employees reviewing themselves
+func mappedReviewers(errs validate.Errors, payload reviewJobPayload, employees map[int]store.Employee) []store.ReviewJobResult {
+	if !payload.Filter.empty() {
+		errs.Add("filter", "is not used with the mapping strategy")
+	}
+	if len(payload.Mapping) == 0 {
+		errs.Add("mapping", "is required with the mapping strategy")
+	}
+
+	results := make([]store.ReviewJobResult, 0, len(payload.Mapping))
+	mapped := map[int]bool{}
+	for i, entry := range payload.Mapping {
+		field := fmt.Sprintf("mapping[%d]", i)
+		if _, ok := employees[entry.EmployeeID]; !ok {
+			errs.Add(field+".employee_id", fmt.Sprintf("employee %d does not exist", entry.EmployeeID))
+		} else if mapped[entry.EmployeeID] {
+			errs.Add(field+".employee_id", fmt.Sprintf("employee %d is mapped twice", entry.EmployeeID))
+		}
+		mapped[entry.EmployeeID] = true
+		for j, reviewerID := range entry.ReviewerIDs {
+			reviewerField := fmt.Sprintf("%s.reviewer_ids[%d]", field, j)
+			if reviewerID == entry.EmployeeID {
+				errs.Add(reviewerField, "must not be the employee being reviewed")
+			} else if _, ok := employees[reviewerID]; !ok {
+				errs.Add(reviewerField, fmt.Sprintf("employee %d does not exist", reviewerID))
+			}
+		}
+		results = append(results, store.ReviewJobResult{EmployeeID: entry.EmployeeID, ReviewerIDs: entry.ReviewerIDs})
+	}
+	return results
+}
+
+// selectForJob returns a result for every employee the filter matches, in ID
+// order, recording in errs what is wrong with the filter and strategy
+func (h *AdminHandler) selectForJob(r *http.Request, errs validate.Errors, payload reviewJobPayload, employees map[int]store.Employee) ([]store.ReviewJobResult, error) {
+	if len(payload.Mapping) > 0 {
+		errs.Add("mapping", "is only used with the mapping strategy")
+	}
+	if payload.Peers != 0 && payload.Strategy != store.StrategyManagerPeers {
+		errs.Add("peers", "is only used with the manager_peers strategy")
+	}
+
+	filter := payload.Filter
+	var selected []int
+	for id := range employees {
+		selected = append(selected, id)
+	}
+	if filter.ManagerID != 0 {
+		if _, ok := employees[filter.ManagerID]; !ok {
+			errs.Add("filter.manager_id", fmt.Sprintf("employee %d does not exist", filter.ManagerID))
+			return nil, nil
+		}
+		depth := 1
+		if filter.Indirect {
+			depth = 0
+		}
+		reports, err := h.employees.Reports(r.Context(), filter.ManagerID, depth)
+		if err != nil {
+			return nil, err
+		}
+		selected = selected[:0]
+		for _, report := range reports {
+			selected = append(selected, report.ID)
+		}
+	} else if filter.Indirect {
+		errs.Add("filter.indirect", "requires manager_id")
+	}
+	for i, id := range filter.EmployeeIDs {
+		if _, ok := employees[id]; !ok {
+			errs.Add(fmt.Sprintf("filter.employee_ids[%d]", i), fmt.Sprintf("employee %d does not exist", id))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil
+	}
+
+	var results []store.ReviewJobResult
+	for _, id := range selected {
+		if filter.Position != "" && employees[id].Position != filter.Position {
+			continue
+		}
+		if len(filter.EmployeeIDs) > 0 && !slices.Contains(filter.EmployeeIDs, id) {
+			continue
+		}
+		results = append(results, store.ReviewJobResult{EmployeeID: id})
+	}
+	slices.SortFunc(results, func(a, b store.ReviewJobResult) int { return a.EmployeeID - b.EmployeeID })
+	return results, nil
+}
+
+// assignReviewers fills in the reviewers of every result: the employee's
+// manager along with peers, the other employees reporting to the same manager.
+// manager_peers picks the given number of peers at random and team takes all
+// of them. Employees without a manager have no team and are skipped, as are
+// those whose team is too large for one review.
+func assignReviewers(results []store.ReviewJobResult, strategy string, peers int, employees []store.Employee) {
+	managers := map[int]int{}
+	teams := map[int][]int{}
+	for _, employee := range employees {
+		managers[employee.ID] = employee.ManagerID
+		if employee.ManagerID != 0 {
+			teams[employee.ManagerID] = append(teams[employee.ManagerID], employee.ID)
+		}
+	}
+
+	for i := range results {
+		result := &results[i]
+		managerID := managers[result.EmployeeID]
+		if managerID == 0 {
+			result.SkipReason = "has no manager"
+			continue
+		}
+		teammates := slices.DeleteFunc(slices.Clone(teams[managerID]), func(id int) bool { return id == result.EmployeeID })
+
+		reviewerIDs := []int{managerID}
+		if strategy == store.StrategyManagerPeers {
+			rand.Shuffle(len(teammates), func(i, j int) { teammates[i], teammates[j] = teammates[j], teammates[i] })
+			teammates = teammates[:min(peers, len(teammates))]
+			slices.Sort(teammates)
+		}
+		reviewerIDs = append(reviewerIDs, teammates...)
+
+		if len(reviewerIDs) > maxJobReviewers {
+			result.SkipReason = fmt.Sprintf("team has more than %d reviewers", maxJobReviewers)
+			continue
+		}
+		result.ReviewerIDs = reviewerIDs
+	}
+}
+
+// skipReviewed marks the results of employees who already have a review in
+// the cycle as skipped. It writes a 500 and returns false when the lookup fails.
+func (h *AdminHandler) skipReviewed(w http.ResponseWriter, r *http.Request, cycleID int, results []store.ReviewJobResult) bool {
+	employeeIDs := make([]int, len(results))
+	for i, result := range results {
+		employeeIDs[i] = result.EmployeeID
+	}
+	existing, err := h.reviews.List(r.Context(), store.ReviewFilter{CycleID: cycleID, EmployeeIDs: employeeIDs}, store.Page{})
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching reviews")
+		return false
+	}
+
+	reviewed := map[int]bool{}
+	for _, review := range existing {
+		reviewed[review.EmployeeID] = true
+	}
+	for i := range results {
+		if reviewed[results[i].EmployeeID] {
+			results[i].ReviewerIDs = nil
+			results[i].SkipReason = "already has a review in this cycle"
+		}
+	}
+	return true
+}
+
+func reviewJobResponse(job store.ReviewJob) types.ReviewJobResponse {
+	response := types.ReviewJobResponse{
+		ID:         job.ID,
+		CycleID:    job.CycleID,
+		TemplateID: job.TemplateID,
+		Strategy:   job.Strategy,
+		CreatedBy:  job.CreatedBy,
+		Results:    make([]types.ReviewJobResultResponse, len(job.Results)),
+		CreatedAt:  job.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	for i, result := range job.Results {
+		if result.SkipReason == "" {
+			response.Created++
+		} else {
+			response.Skipped++
+		}
+		reviewerIDs := result.ReviewerIDs
+		if reviewerIDs == nil {
+			reviewerIDs = []int{}
+		}
+		response.Results[i] = types.ReviewJobResultResponse{
+			EmployeeID:  result.EmployeeID,
+			ReviewID:    result.ReviewID,
+			ReviewerIDs: reviewerIDs,
+			SkipReason:  result.SkipReason,
+		}
+	}
+	return response
+}
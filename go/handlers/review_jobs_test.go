@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+
+	"go-api/store"
+)
+
+func TestAssignReviewers(t *testing.T) {
+	// Employees 1 and 2 have no manager, 3 and 4 report to 1 and 5 reports to 2
+	employees := []store.Employee{{ID: 1}, {ID: 2}, {ID: 3, ManagerID: 1}, {ID: 4, ManagerID: 1}, {ID: 5, ManagerID: 2}}
+	tests := []struct {
+		name       string
+		employeeID int
+		wantIDs    []int
+		wantSkip   string
+	}{
+		{name: "no manager", employeeID: 1, wantSkip: "has no manager"},
+		{name: "manager and peer", employeeID: 3, wantIDs: []int{1, 4}},
+		{name: "manager only", employeeID: 5, wantIDs: []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := []store.ReviewJobResult{{EmployeeID: tt.employeeID}}
+			assignReviewers(results, store.StrategyTeam, 0, employees)
+			if results[0].SkipReason != tt.wantSkip {
+				t.Errorf("skip reason = %q, want %q", results[0].SkipReason, tt.wantSkip)
+			}
+			if !slices.Equal(results[0].ReviewerIDs, tt.wantIDs) {
+				t.Errorf("reviewers = %v, want %v", results[0].ReviewerIDs, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"go-api/store"
+	"go-api/types"
+	"go-api/validate"
+)
+
+// transitionPayload is the request body for changing a review's status
+type transitionPayload struct {
+	To string `json:"to" validate:"required"`
+}
+
+// TransitionReview godoc
+// @Summary Change a review's status
+// @Description Moves a review through its workflow: draft, in_progress, submitted, shared, acknowledged, archived
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param transition body handlers.transitionPayload true "Target status"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 200 {object} types.ReviewTransitionResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/reviews/{id}/transitions [post]
+func (h *AdminHandler) TransitionReview(w http.ResponseWriter, r *http.Request) {
+	review, ok := fetchReview(w, r, h.reviews)
+	if !ok {
+		return
+	}
+
+	claims, _ := ClaimsFromContext(r.Context())
+	var actorID int
+	if claims != nil {
+		actorID = claims.ID
+	}
+	applyTransition(w, r, h.reviews, review, store.ActorAdmin, actorID)
+}
+
+// GetReviewTransitions godoc
+// @Summary Get a review's status history
+// @Description Lists every status change of a review, oldest first
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {array} types.ReviewTransitionResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/reviews/{id}/transitions [get]
+func (h *AdminHandler) GetReviewTransitions(w http.ResponseWriter, r *http.Request) {
+	review, ok := fetchReview(w, r, h.reviews)
+	if !ok {
+		return
+	}
+
+	stored, err := h.reviews.ListTransitions(r.Context(), review.ID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review transitions")
+		return
+	}
+
+	transitions := []types.ReviewTransitionResponse{}
+	for _, transition := range stored {
+		transitions = append(transitions, transitionResponse(transition))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(transitions); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// AcknowledgeReview godoc
+// @Summary Change the status of a review about yourself
+// @Description Lets the reviewed employee acknowledge a review that has been shared with them
+// @Tags Employee
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param transition body handlers.transitionPayload true "Target status"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 200 {object} types.ReviewTransitionResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /employee/reviews/{id}/transitions [post]
+func (h *EmployeeHandler) AcknowledgeReview(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	review, ok := fetchReview(w, r, h.reviews)
+	if !ok {
+		return
+	}
+	if review.EmployeeID != employee.ID {
+		WriteError(w, r, http.StatusForbidden, CodeNotReviewSubject, "Only the reviewed employee may change this review")
+		return
+	}
+
+	claims, _ := ClaimsFromContext(r.Context())
+	applyTransition(w, r, h.reviews, review, store.ActorReviewee, claims.ID)
+}
+
+// fetchReview loads the review named by the {id} path parameter, writing the
+// error response and returning false if it cannot
+func fetchReview(w http.ResponseWriter, r *http.Request, reviews store.ReviewStore) (store.Review, bool) {
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return store.Review{}, false
+	}
+
+	review, err := reviews.Get(r.Context(), reviewID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeReviewNotFound, "Review not found")
+		return store.Review{}, false
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review")
+		return store.Review{}, false
+	}
+	return review, true
+}
+
+// applyTransition decodes the target status, checks the workflow rules for the
+// actor and moves the review, writing the response
+func applyTransition(w http.ResponseWriter, r *http.Request, reviews store.ReviewStore, review store.Review, actor string, actorID int) {
+	var payload transitionPayload
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+	if !store.IsReviewStatus(payload.To) {
+		writeValidationErrors(w, r, validate.Errors{"to": "is not a review status"})
+		return
+	}
+
+	err := store.CheckReviewTransition(review.Status, payload.To, actor)
+	if errors.Is(err, store.ErrTransitionForbidden) {
+		WriteError(w, r, http.StatusForbidden, CodeTransitionNotAllowed, "Not allowed to move review from "+review.Status+" to "+payload.To)
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusConflict, CodeTransitionInvalid, "Cannot move review from "+review.Status+" to "+payload.To)
+		return
+	}
+
+	transition, err := reviews.Transition(r.Context(), review.ID, review.Status, payload.To, actorID)
+	if errors.Is(err, store.ErrStatusChanged) {
+		WriteError(w, r, http.StatusConflict, CodeReviewStatusChanged, "Review status changed, reload and try again")
+		return
+	}
+	if err != nil {
+		log.Printf("Error changing review status: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error changing review status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(transitionResponse(transition)); err != nil {
+		log.Printf("Error encoding review transition: %v", err)
+	}
+}
+
+func transitionResponse(transition store.ReviewTransition) types.ReviewTransitionResponse {
+	return types.ReviewTransitionResponse{
+		ID:         transition.ID,
+		ReviewID:   transition.ReviewID,
+		FromStatus: transition.FromStatus,
+		ToStatus:   transition.ToStatus,
+		ActorID:    transition.ActorID,
+		CreatedAt:  transition.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"go-api/store"
+	"go-api/types"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// /roles handlers
+
+// GetRoles godoc
+// @Summary Get all roles
+// @Description Lists every role with the permissions it grants
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} types.RoleResponse
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/roles [get]
+func (h *AdminHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	stored, err := h.roles.List(r.Context())
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching roles")
+		return
+	}
+
+	roles := []types.RoleResponse{}
+	for _, role := range stored {
+		permissions := role.Permissions
+		if permissions == nil {
+			permissions = []string{}
+		}
+		roles = append(roles, types.RoleResponse{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: permissions,
+			MFARequired: role.MFARequired,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(roles); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// SetEmployeeRole godoc
+// @Summary Set an employee's role
+// @Description Gives the employee's login account another role, e.g. hr_partner or auditor.
+// @Description The change applies from the employee's next login.
+// @Tags Admin
+// @Accept json
+// @Param id path int true "Employee ID"
+// @Param role body object true "Role name"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/employees/{id}/role [put]
+func (h *AdminHandler) SetEmployeeRole(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := pathID(w, r, "employee")
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Role string `json:"role" validate:"required,max=64"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	employee, err := h.employees.Get(r.Context(), employeeID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeEmployeeNotFound, "Employee not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching employee")
+		return
+	}
+
+	err = h.users.SetRole(r.Context(), employee.Email, payload.Role)
+	if errors.Is(err, store.ErrUnknownRole) {
+		WriteError(w, r, http.StatusBadRequest, CodeRoleNotFound, "Unknown role")
+		return
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeAccountNotFound, "Employee has no login account")
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting role: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error setting role")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetRoleMFA godoc
+// @Summary Require two-factor authentication for a role
+// @Description Makes users with the role set up an authenticator at their next login, or stops requiring it
+// @Tags Admin
+// @Accept json
+// @Param name path string true "Role name"
+// @Param request body object true "required"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/roles/{name}/mfa [put]
+func (h *AdminHandler) SetRoleMFA(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Required *bool `json:"required" validate:"required"`
+	}
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	err := h.roles.SetMFARequired(r.Context(), router.URLParam(r, "name"), *payload.Required)
+	if errors.Is(err, store.ErrUnknownRole) {
+		WriteError(w, r, http.StatusNotFound, CodeRoleNotFound, "Role not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting two-factor requirement: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error updating role")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"go-api/store"
+	"go-api/types"
+	"go-api/validate"
+)
+
+// /templates handlers
+
+// templatePayload is the request body for creating review templates
+type templatePayload struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=1000"`
+	Sections    []struct {
+		Title     string `json:"title" validate:"required,max=200"`
+		Questions []struct {
+			Prompt   string   `json:"prompt" validate:"required,max=1000"`
+			Type     string   `json:"type" validate:"required,oneof=rating choice text"`
+			Required bool     `json:"required"`
+			Options  []string `json:"options" validate:"unique,max=20"`
+		} `json:"questions" validate:"required,max=50"`
+	} `json:"sections" validate:"required,max=20"`
+}
+
+// toTemplate converts the payload to a store.Template, checking the rules the
+// tags cannot express such as which question types take options
+func (p templatePayload) toTemplate() (store.Template, error) {
+	template := store.Template{Name: p.Name, Description: p.Description}
+	for _, section := range p.Sections {
+		templateSection := store.TemplateSection{Title: section.Title}
+		for _, question := range section.Questions {
+			templateSection.Questions = append(templateSection.Questions, store.TemplateQuestion{
+				Prompt:   question.Prompt,
+				Type:     question.Type,
+				Required: question.Required,
+				Options:  question.Options,
+			})
+		}
+		template.Sections = append(template.Sections, templateSection)
+	}
+	return template, template.Validate()
+}
+
+// AddTemplate godoc
+// @Summary Add a review template
+// @Description Creates a review template made of sections of rating (1-5), choice and free-text questions.
+// @Description Templates cannot be edited afterwards; create a new one instead.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param template body object true "Template with its sections and questions"
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 201 {object} types.TemplateResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/templates [post]
+func (h *AdminHandler) AddTemplate(w http.ResponseWriter, r *http.Request) {
+	var payload templatePayload
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	template, err := payload.toTemplate()
+	var templateErr *store.TemplateError
+	if errors.As(err, &templateErr) {
+		writeValidationErrors(w, r, validate.Errors{templateErr.Field: templateErr.Message})
+		return
+	}
+
+	template, err = h.templates.Create(r.Context(), template)
+	if err != nil {
+		log.Printf("Error adding review template: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error adding review template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(templateResponse(template)); err != nil {
+		log.Printf("Error encoding review template: %v", err)
+	}
+}
+
+// GetTemplates godoc
+// @Summary Get all review templates
+// @Description Retrieves every review template with its questions
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} types.TemplateResponse
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/templates [get]
+func (h *AdminHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	stored, err := h.templates.List(r.Context())
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review templates")
+		return
+	}
+
+	templates := []types.TemplateResponse{}
+	for _, template := range stored {
+		templates = append(templates, templateResponse(template))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(templates); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// GetTemplate godoc
+// @Summary Get a review template
+// @Description Retrieves a single review template with its questions
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} types.TemplateResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/templates/{id} [get]
+func (h *AdminHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "template")
+	if !ok {
+		return
+	}
+
+	template, err := h.templates.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeTemplateNotFound, "Review template not found")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(templateResponse(template)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// RemoveTemplate godoc
+// @Summary Remove a review template
+// @Description Deletes a review template that no review was created from
+// @Tags Admin
+// @Param id path int true "Template ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /admin/templates/{id} [delete]
+func (h *AdminHandler) RemoveTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "template")
+	if !ok {
+		return
+	}
+
+	err := h.templates.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusNotFound, CodeTemplateNotFound, "Review template not found")
+		return
+	}
+	if errors.Is(err, store.ErrTemplateInUse) {
+		WriteError(w, r, http.StatusConflict, CodeTemplateInUse, "Review template is used by reviews")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error removing review template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetReviewTemplate godoc
+// @Summary Get the template of a review you are assigned to
+// @Description Returns the questions to answer when giving feedback on a templated review
+// @Tags Employee
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} types.TemplateResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Forbidden"
+// @Failure 404 {object} types.ErrorResponse "Not Found"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /employee/reviews/{id}/template [get]
+func (h *EmployeeHandler) GetReviewTemplate(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.currentEmployee(r)
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Invalid ID")
+		return
+	}
+
+	reviewID, ok := pathID(w, r, "review")
+	if !ok {
+		return
+	}
+
+	isReviewer, err := h.reviews.IsReviewer(r.Context(), reviewID, employee.ID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error validating reviewer status")
+		return
+	}
+	if !isReviewer {
+		WriteError(w, r, http.StatusForbidden, CodeNotAReviewer, "Unauthorized to review this performance review")
+		return
+	}
+
+	review, err := h.reviews.Get(r.Context(), reviewID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review")
+		return
+	}
+	if review.TemplateID == 0 {
+		WriteError(w, r, http.StatusNotFound, CodeReviewHasNoTemplate, "Review has no template")
+		return
+	}
+	template, err := h.templates.Get(r.Context(), review.TemplateID)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error fetching review template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(templateResponse(template)); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// errAnswersInvalid wraps answer validation failures so handlers can report them as bad requests
+var errAnswersInvalid = errors.New("invalid answers")
+
+// reviewAnswers decodes answers keyed by question ID and validates them against
+// the review's template, requiring every required question when complete is set
+func reviewAnswers(ctx context.Context, templates store.TemplateStore, review store.Review, raw map[string]json.RawMessage, complete bool) ([]store.Answer, error) {
+	if review.TemplateID == 0 {
+		if len(raw) > 0 {
+			return nil, fmt.Errorf("%w: review has no template", errAnswersInvalid)
+		}
+		return nil, nil
+	}
+
+	template, err := templates.Get(ctx, review.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := decodeAnswers(template, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errAnswersInvalid, err)
+	}
+	if err := template.ValidateAnswers(answers, complete); err != nil {
+		return nil, fmt.Errorf("%w: %w", errAnswersInvalid, err)
+	}
+	return answers, nil
+}
+
+// checkAnswersComplete validates answers that are already stored, e.g. a draft being submitted
+func checkAnswersComplete(ctx context.Context, templates store.TemplateStore, review store.Review, answers []store.Answer) error {
+	if review.TemplateID == 0 {
+		return nil
+	}
+	template, err := templates.Get(ctx, review.TemplateID)
+	if err != nil {
+		return err
+	}
+	if err := template.ValidateAnswers(answers, true); err != nil {
+		return fmt.Errorf("%w: %w", errAnswersInvalid, err)
+	}
+	return nil
+}
+
+// decodeAnswers converts the raw JSON answers to store answers in template order.
+// Ratings are numbers, choice and text answers are strings.
+func decodeAnswers(template store.Template, raw map[string]json.RawMessage) ([]store.Answer, error) {
+	for key := range raw {
+		id, err := strconv.Atoi(key)
+		if _, ok := template.Question(id); err != nil || !ok {
+			return nil, fmt.Errorf("question %s is not part of the review template", key)
+		}
+	}
+
+	var answers []store.Answer
+	for _, section := range template.Sections {
+		for _, question := range section.Questions {
+			// Unanswered questions may be left out or sent as null
+			value, ok := raw[strconv.Itoa(question.ID)]
+			if !ok || string(value) == "null" {
+				continue
+			}
+
+			answer := store.Answer{QuestionID: question.ID}
+			var err error
+			switch question.Type {
+			case store.QuestionRating:
+				err = json.Unmarshal(value, &answer.Rating)
+			case store.QuestionChoice:
+				err = json.Unmarshal(value, &answer.Choice)
+			default:
+				err = json.Unmarshal(value, &answer.Text)
+			}
+			if err != nil {
+				if question.Type == store.QuestionRating {
+					return nil, fmt.Errorf("question %d: rating must be a whole number", question.ID)
+				}
+				return nil, fmt.Errorf("question %d: answer must be a string", question.ID)
+			}
+			answers = append(answers, answer)
+		}
+	}
+	return answers, nil
+}
+
+// aggregateRatings averages the rating answers of the given feedback per question
+func aggregateRatings(comments []types.FeedbackResponse) []types.QuestionRatingResponse {
+	totals := map[int]*types.QuestionRatingResponse{}
+	for _, comment := range comments {
+		for _, answer := range comment.Answers {
+			if answer.Rating == 0 {
+				continue
+			}
+			total, ok := totals[answer.QuestionID]
+			if !ok {
+				total = &types.QuestionRatingResponse{QuestionID: answer.QuestionID}
+				totals[answer.QuestionID] = total
+			}
+			// Accumulate the sum in Average until every answer is counted
+			total.Average += float64(answer.Rating)
+			total.Count++
+		}
+	}
+
+	ratings := make([]types.QuestionRatingResponse, 0, len(totals))
+	for _, total := range totals {
+		total.Average = math.Round(total.Average/float64(total.Count)*100) / 100
+		ratings = append(ratings, *total)
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		return ratings[i].QuestionID < ratings[j].QuestionID
+	})
+	return ratings
+}
+
+func templateResponse(template store.Template) types.TemplateResponse {
+	response := types.TemplateResponse{
+		ID:          template.ID,
+		Name:        template.Name,
+		Description: template.Description,
+		Sections:    []types.TemplateSectionResponse{},
+		CreatedAt:   template.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	for _, section := range template.Sections {
+		sectionResponse := types.TemplateSectionResponse{
+			ID:        section.ID,
+			Title:     section.Title,
+			Questions: []types.TemplateQuestionResponse{},
+		}
+		for _, question := range section.Questions {
+			sectionResponse.Questions = append(sectionResponse.Questions, types.TemplateQuestionResponse{
+				ID:       question.ID,
+				Prompt:   question.Prompt,
+				Type:     question.Type,
+				Required: question.Required,
+				Options:  question.Options,
+			})
+		}
+		response.Sections = append(response.Sections, sectionResponse)
+	}
+	return response
+}
+
+func answerResponses(answers []store.Answer) []types.AnswerResponse {
+	if len(answers) == 0 {
+		return nil
+	}
+	responses := make([]types.AnswerResponse, 0, len(answers))
+	for _, answer := range answers {
+		responses = append(responses, types.AnswerResponse{
+			QuestionID: answer.QuestionID,
+			Rating:     answer.Rating,
+			Choice:     answer.Choice,
+			Text:       answer.Text,
+		})
+	}
+	return responses
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"go-api/store"
+)
+
+const (
+	// AccessTokenTTL is how long an access token is accepted
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a refresh token can be exchanged for a new pair
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// RefreshRequest carries the refresh token to exchange
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// RefreshToken godoc
+// @Summary Refresh the access token
+// @Description Exchanges a refresh token for a new access and refresh token. Each refresh token can be used once; using one again revokes every token of its login session.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body handlers.RefreshRequest true "Refresh token"
+// @Success 200 {object} types.TokenResponse
+// @Failure 400 {object} types.ErrorResponse "Bad Request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /token/refresh [post]
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshRequest
+	if !decodePayload(w, r, &payload) {
+		return
+	}
+
+	refreshToken, next, err := newTokenPair()
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return
+	}
+	record, err := h.tokens.Rotate(r.Context(), hashToken(payload.RefreshToken), next)
+	switch {
+	case errors.Is(err, store.ErrTokenReused):
+		log.Printf("Refresh token reused, session revoked")
+		WriteError(w, r, http.StatusUnauthorized, CodeRefreshTokenInvalid, "Invalid refresh token")
+		return
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrTokenRevoked):
+		WriteError(w, r, http.StatusUnauthorized, CodeRefreshTokenInvalid, "Invalid refresh token")
+		return
+	case err != nil:
+		log.Printf("Error rotating refresh token: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return
+	}
+
+	user, err := h.users.Get(r.Context(), record.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		WriteError(w, r, http.StatusUnauthorized, CodeRefreshTokenInvalid, "Invalid refresh token")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Could not create token")
+		return
+	}
+
+	h.writeTokens(w, r, user, record, refreshToken, nil)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revokes the access token and every refresh token of its login session
+// @Tags Authentication
+// @Security BearerAuth
+// @Param Idempotency-Key header string false "Unique key that makes retries of the request replay its first response"
+// @Success 204 {string} string "No Content"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 409 {object} types.ErrorResponse "Conflict"
+// @Failure 422 {object} types.ErrorResponse "Unprocessable Entity"
+// @Failure 500 {object} types.ErrorResponse "Internal Server Error"
+// @Router /logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// The access token was issued with the family's latest refresh token, so
+	// revoking the family revokes it too
+	if err := h.tokens.RevokeFamily(r.Context(), claims.SessionID); err != nil {
+		log.Printf("Error revoking session: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Error logging out")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newTokenPair generates a refresh token and the record to store for it along
+// with the ID and expiry of the access token issued together with it. The
+// caller fills in the family and user.
+func newTokenPair() (string, store.RefreshToken, error) {
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return "", store.RefreshToken{}, err
+	}
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", store.RefreshToken{}, err
+	}
+
+	now := time.Now().UTC()
+	return refreshToken, store.RefreshToken{
+		TokenHash:       hashToken(refreshToken),
+		AccessJTI:       jti,
+		AccessExpiresAt: now.Add(AccessTokenTTL),
+		ExpiresAt:       now.Add(RefreshTokenTTL),
+	}, nil
+}
+
+// randomToken returns n random bytes encoded for use in URLs and headers
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a refresh token, which is what gets stored.
+// Refresh tokens are random, so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// JWKS godoc
+// @Summary Public signing keys
+// @Description Lists the public keys tokens are signed with as a JSON Web Key Set, so other services can verify them. HS256 keys are not published.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} keys.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(h.keys.JWKS()); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-api/store"
+	"go-api/validate"
+
+	"github.com/jtclarkjr/router-go"
+)
+
+// decodePayload decodes the JSON request body into payload and checks it
+// against its validate tags. It writes a 400 for malformed JSON or a 422
+// listing the invalid fields and returns false when the payload is refused.
+func decodePayload(w http.ResponseWriter, r *http.Request, payload any) bool {
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload")
+		return false
+	}
+	if errs := validate.Struct(payload); len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return false
+	}
+	return true
+}
+
+// writeValidationErrors responds with 422 and the problem of each field under
+// details.fields, e.g. {"fields": {"email": "must be a valid email address"}}
+func writeValidationErrors(w http.ResponseWriter, r *http.Request, errs validate.Errors) {
+	WriteErrorDetails(w, r, http.StatusUnprocessableEntity, CodeValidationFailed, "Validation failed: "+errs.Error(), map[string]any{"fields": errs})
+}
+
+// writeConstraintError responds with 409 for store.ErrConflict and 422 for
+// store.ErrInvalidReference, which the database reports when a record changed
+// after the handler's own checks. It returns false for any other error.
+func writeConstraintError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, store.ErrConflict):
+		WriteError(w, r, http.StatusConflict, CodeConflict, "Request conflicts with an existing record")
+	case errors.Is(err, store.ErrInvalidReference):
+		WriteError(w, r, http.StatusUnprocessableEntity, CodeInvalidReference, "Request refers to a record that does not exist")
+	default:
+		return false
+	}
+	return true
+}
+
+// pathID parses the {id} path parameter before anything is looked up by it.
+// IDs are positive integers; anything else gets a 400 naming what the ID is
+// of, e.g. "Invalid review ID", and false is returned.
+func pathID(w http.ResponseWriter, r *http.Request, what string) (int, bool) {
+	return pathParamID(w, r, "id", what)
+}
+
+// pathParamID is pathID for a path parameter with another name, such as {reviewer_id}
+func pathParamID(w http.ResponseWriter, r *http.Request, name, what string) (int, bool) {
+	id, err := strconv.Atoi(router.URLParam(r, name))
+	if err != nil || id <= 0 {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidRequest, "Invalid "+what+" ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,205 @@
+// Package keys holds the keys used to sign and verify JWTs. A keyring has one
+// current key that signs new tokens and any number of previous keys that are
+// still accepted, so keys can be rotated without logging everyone out.
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// minSecretLength is the shortest HS256 secret accepted from configuration
+const minSecretLength = 32
+
+// devSecret signs tokens when no key is configured; never use it in production
+const devSecret = "your_secret_key"
+
+// Key is a signing key identified by the kid header of the tokens it signs
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// signKey is nil for keys that can only verify
+	signKey   any
+	verifyKey any
+}
+
+// NewHMACKey returns an HS256 key for the shared secret
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// NewKey returns a key for an RSA, P-256 ECDSA or Ed25519 private or public
+// key, signing with RS256, ES256 or EdDSA respectively. Public keys only verify.
+func NewKey(id string, key crypto.PublicKey) (*Key, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("key %s: only P-256 ECDSA keys are supported", id)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodES256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("key %s: only P-256 ECDSA keys are supported", id)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodES256, verifyKey: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: SigningMethodEdDSA, verifyKey: k}, nil
+	default:
+		return nil, fmt.Errorf("key %s: unsupported key type %T", id, key)
+	}
+}
+
+// Keyring holds the current signing key and the previous keys still accepted
+type Keyring struct {
+	current *Key
+	keys    map[string]*Key
+	// ordered lists the keys current first, for publishing
+	ordered []*Key
+}
+
+// New returns a keyring signing with current and also accepting previous
+func New(current *Key, previous ...*Key) (*Keyring, error) {
+	if current == nil || current.signKey == nil {
+		return nil, errors.New("the current key must be able to sign")
+	}
+	return newKeyring(current, append([]*Key{current}, previous...))
+}
+
+// NewVerifier returns a keyring that only verifies tokens signed by the keys
+func NewVerifier(keys ...*Key) (*Keyring, error) {
+	return newKeyring(nil, keys)
+}
+
+// newKeyring indexes keys by ID, rejecting duplicates
+func newKeyring(current *Key, keys []*Key) (*Keyring, error) {
+	keyring := &Keyring{current: current, keys: map[string]*Key{}}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("every key needs an ID")
+		}
+		if _, ok := keyring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %s", key.ID)
+		}
+		keyring.keys[key.ID] = key
+		keyring.ordered = append(keyring.ordered, key)
+	}
+	return keyring, nil
+}
+
+// Load builds the keyring from the environment:
+//
+//   - JWT_KEY_FILES is a comma-separated list of key files, the current key
+//     first. Files hold a PEM private key (RSA, P-256 ECDSA or Ed25519), a PEM
+//     public key for retired keys that only verify, or an HS256 secret. The file
+//     name without its extension is the key ID.
+//   - Otherwise JWT_SECRET is used as a single HS256 secret.
+//
+// Without either, tokens are signed with a built-in development secret.
+func Load() (*Keyring, error) {
+	if files := os.Getenv("JWT_KEY_FILES"); files != "" {
+		var keys []*Key
+		for _, path := range strings.Split(files, ",") {
+			key, err := LoadFile(strings.TrimSpace(path))
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+		return New(keys[0], keys[1:]...)
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		if len(secret) < minSecretLength {
+			return nil, fmt.Errorf("JWT_SECRET must be at least %d bytes", minSecretLength)
+		}
+		return New(NewHMACKey("default", []byte(secret)))
+	}
+
+	log.Println("No JWT_KEY_FILES or JWT_SECRET set, signing tokens with the development secret")
+	return New(NewHMACKey("dev", []byte(devSecret)))
+}
+
+// LoadFile reads a key file; see Load for the formats
+func LoadFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < minSecretLength {
+			return nil, fmt.Errorf("key %s: HS256 secrets must be at least %d bytes", id, minSecretLength)
+		}
+		return NewHMACKey(id, secret), nil
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %s: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+	return NewKey(id, key)
+}
+
+// Sign signs the claims with the current key, naming it in the kid header
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	if k.current == nil {
+		return "", errors.New("keyring cannot sign")
+	}
+	token := jwt.NewWithClaims(k.current.Method, claims)
+	token.Header["kid"] = k.current.ID
+	return token.SignedString(k.current.signKey)
+}
+
+// Parse verifies a token signed by any key of the keyring and decodes its claims.
+// The token must name its key, unless the keyring holds a single key, and use
+// that key's algorithm.
+func (k *Keyring) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		id, _ := token.Header["kid"].(string)
+		key, ok := k.keys[id]
+		if id == "" && len(k.ordered) == 1 {
+			key, ok = k.ordered[0], true
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID %q", id)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("key %s does not sign with %s", id, token.Method.Alg())
+		}
+		return key.verifyKey, nil
+	})
+}
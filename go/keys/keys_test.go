@@ -0,0 +1,73 @@
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestKeyringParse(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	current, err := NewKey("current", private)
+	if err != nil {
+		t.Fatalf("creating key: %v", err)
+	}
+	previousSecret := []byte("a-previous-secret-of-at-least-32-bytes")
+	keyring, err := New(current, NewHMACKey("previous", previousSecret))
+	if err != nil {
+		t.Fatalf("creating keyring: %v", err)
+	}
+	// An attacker knowing the public key may try to use it as an HS256 secret
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatalf("encoding public key: %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key any) string {
+		token := jwt.NewWithClaims(method, jwt.StandardClaims{Subject: "1"})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("signing token: %v", err)
+		}
+		return signed
+	}
+	currentToken, err := keyring.Sign(jwt.StandardClaims{Subject: "1"})
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "current key", token: currentToken},
+		{name: "previous key", token: sign(jwt.SigningMethodHS256, "previous", previousSecret)},
+		{name: "unknown key ID", token: sign(jwt.SigningMethodHS256, "retired", previousSecret), wantErr: true},
+		{name: "no key ID with several keys", token: sign(jwt.SigningMethodHS256, "", previousSecret), wantErr: true},
+		{name: "HS256 with the ECDSA public key", token: sign(jwt.SigningMethodHS256, "current", publicDER), wantErr: true},
+		{name: "ES256 under an HS256 key ID", token: sign(jwt.SigningMethodES256, "previous", private), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwt.StandardClaims
+			_, err := keyring.Parse(tt.token, &claims)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && claims.Subject != "1" {
+				t.Errorf("subject = %q, want %q", claims.Subject, "1")
+			}
+		})
+	}
+}
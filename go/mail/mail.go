@@ -0,0 +1,71 @@
+// Package mail sends the emails the API needs, such as password reset links,
+// through a pluggable Sender.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// LogSender writes messages to the log instead of sending them, for local runs
+type LogSender struct{}
+
+func (LogSender) Send(_ context.Context, message Message) error {
+	log.Printf("Mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+// SMTPSender sends messages through an SMTP server
+type SMTPSender struct {
+	// Addr is the server's host:port
+	Addr string
+	From string
+	// Auth is nil for servers that accept unauthenticated mail
+	Auth smtp.Auth
+}
+
+func (s SMTPSender) Send(_ context.Context, message Message) error {
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return fmt.Errorf("invalid header in message to %q", message.To)
+	}
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		s.From, message.To, message.Subject, strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{message.To}, []byte(body))
+}
+
+// FromEnv returns an SMTPSender when SMTP_ADDR is set, using SMTP_FROM and,
+// when SMTP_USERNAME is set, SMTP_PASSWORD. Otherwise mail is only logged.
+func FromEnv() Sender {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		log.Println("No SMTP_ADDR set, mail is written to the log")
+		return LogSender{}
+	}
+
+	sender := SMTPSender{Addr: addr, From: os.Getenv("SMTP_FROM")}
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		sender.Auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return sender
+}
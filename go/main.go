@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
 
 	"go-api/db"
 	_ "go-api/docs"
 	"go-api/handlers"
+	"go-api/keys"
+	"go-api/mail"
 	"go-api/middlewares"
+	"go-api/oidc"
+	"go-api/passwords"
+	"go-api/store"
+	"go-api/store/memory"
+	"go-api/store/postgres"
 
 	"github.com/jtclarkjr/router-go"
 	"github.com/jtclarkjr/router-go/middleware"
@@ -26,41 +35,181 @@ import (
 // @description Provide your token with prefix "Bearer "
 // @schemes https http
 func main() {
-	// Initialize database connection
-	db.Connect()
-	db.SeedDatabase()
+	// Schema management subcommand: go run . migrate up|down|status|to N
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(db.Connect(), os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	stores := openStores()
+	if err := store.Seed(context.Background(), stores); err != nil {
+		log.Fatalf("Error seeding database: %v", err)
+	}
+
+	keyring, err := keys.Load()
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+
+	policy, err := passwords.LoadPolicy()
+	if err != nil {
+		log.Fatalf("Error loading password policy: %v", err)
+	}
+
+	idempotencyTTL, err := middlewares.IdempotencyTTLFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring idempotency keys: %v", err)
+	}
+
+	authHandler := handlers.NewAuthHandler(stores, keyring)
+	passwordHandler := handlers.NewPasswordHandler(stores, authHandler, policy, mail.FromEnv(), os.Getenv("PASSWORD_RESET_URL"))
+	adminHandler := handlers.NewAdminHandler(stores, policy, passwordHandler)
+	employeeHandler := handlers.NewEmployeeHandler(stores)
+	managerHandler := handlers.NewManagerHandler(stores)
 
 	r := router.NewRouter()
+	r.Use(middlewares.RequestID)
 	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(middlewares.Recoverer)
 
 	// Swagger route
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 
-	// Login to generate token route
-	r.Post("/login", handlers.Login)
+	// Every route states the permissions it needs; see store.DefaultRoles for who holds them.
+	// Routes managing the account itself use requireLogin, which refuses API keys.
+	authenticator := middlewares.NewAuthenticator(stores, keyring)
+	require, requireLogin := authenticator.Require, authenticator.RequireLogin
+
+	// POST routes replay their response when retried with the same Idempotency-Key.
+	// Routes answering with tokens, secrets or recovery codes are left out so that
+	// those are never stored.
+	idempotent := middlewares.NewIdempotency(stores, idempotencyTTL).Wrap
+
+	// Token routes
+	r.Post("/login", authHandler.Login)
+	r.Post("/login/mfa", authHandler.LoginMFA)
+	r.Post("/login/mfa/enroll", authHandler.LoginMFAEnroll)
+	r.Post("/token/refresh", authHandler.RefreshToken)
+	r.Post("/logout", requireLogin()(idempotent(authHandler.Logout)))
+	r.Get("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Password routes
+	r.Post("/me/password", requireLogin()(passwordHandler.ChangePassword))
+	r.Post("/password/forgot", idempotent(passwordHandler.ForgotPassword))
+	r.Post("/password/reset", idempotent(passwordHandler.ResetPassword))
+
+	// Two-factor authentication routes
+	r.Post("/me/mfa/totp", requireLogin()(authHandler.StartTOTP))
+	r.Post("/me/mfa/totp/confirm", requireLogin()(authHandler.ConfirmTOTP))
+	r.Delete("/me/mfa/totp", requireLogin()(authHandler.DisableTOTP))
 
-	r.Route("/admin", func(r *router.Router) {
-		r.Use(middlewares.AuthAdmin)
-		r.Post("/employees", handlers.AddEmployee)
-		r.Get("/employees", handlers.GetEmployees)
-		r.Put("/employees/{id}", handlers.UpdateEmployee)
-		r.Delete("/employees/{id}", handlers.RemoveEmployee)
+	// Personal API key routes
+	r.Post("/me/api-keys", requireLogin()(authHandler.CreateAPIKey))
+	r.Get("/me/api-keys", requireLogin()(authHandler.GetAPIKeys))
+	r.Delete("/me/api-keys/{id}", requireLogin()(authHandler.RevokeAPIKey))
 
-		r.Post("/reviews", handlers.AddReview)
-		r.Get("/reviews", handlers.GetReviews)
-		r.Put("/reviews/{id}/comments", handlers.UpdateReview)
-	})
+	// Single sign-on, when an identity provider is configured
+	if oidcHandler := openOIDC(authHandler); oidcHandler != nil {
+		r.Get("/auth/oidc/login", oidcHandler.Login)
+		r.Get("/auth/oidc/callback", oidcHandler.Callback)
+	}
+
+	// Admin routes
+	r.Post("/admin/employees", require(store.PermEmployeesManage)(idempotent(adminHandler.AddEmployee)))
+	r.Post("/admin/employees/import", require(store.PermEmployeesManage)(idempotent(adminHandler.ImportEmployees)))
+	r.Get("/admin/employees", require(store.PermEmployeesRead)(adminHandler.GetEmployees))
+	r.Get("/admin/employees/{id}", require(store.PermEmployeesRead)(adminHandler.GetEmployee))
+	r.Put("/admin/employees/{id}", require(store.PermEmployeesManage)(adminHandler.UpdateEmployee))
+	r.Patch("/admin/employees/{id}", require(store.PermEmployeesManage)(adminHandler.PatchEmployee))
+	r.Delete("/admin/employees/{id}", require(store.PermEmployeesManage)(adminHandler.RemoveEmployee))
+	r.Put("/admin/employees/{id}/manager", require(store.PermEmployeesManage)(adminHandler.SetEmployeeManager))
+	r.Get("/admin/employees/{id}/reports", require(store.PermEmployeesRead)(adminHandler.GetEmployeeReports))
+	r.Put("/admin/employees/{id}/role", require(store.PermEmployeesManage)(adminHandler.SetEmployeeRole))
+	r.Get("/admin/roles", require(store.PermEmployeesManage)(adminHandler.GetRoles))
+	r.Put("/admin/roles/{name}/mfa", require(store.PermEmployeesManage)(adminHandler.SetRoleMFA))
+	r.Get("/admin/lockouts", require(store.PermEmployeesManage)(adminHandler.GetLockouts))
+	r.Delete("/admin/lockouts", require(store.PermEmployeesManage)(adminHandler.Unlock))
+
+	r.Post("/admin/reviews", require(store.PermReviewsWriteAny)(idempotent(adminHandler.AddReview)))
+	r.Get("/admin/reviews", require(store.PermReviewsReadAny)(adminHandler.GetReviews))
+	r.Get("/admin/reviews/{id}", require(store.PermReviewsReadAny)(adminHandler.GetReview))
+	r.Put("/admin/reviews/{id}/comments", require(store.PermReviewsWriteAny)(adminHandler.UpdateReview))
+	r.Patch("/admin/reviews/{id}", require(store.PermReviewsWriteAny)(adminHandler.PatchReview))
+	r.Post("/admin/reviews/{id}/reviewers/{reviewer_id}", require(store.PermReviewsWriteAny)(idempotent(adminHandler.AddReviewer)))
+	r.Delete("/admin/reviews/{id}/reviewers/{reviewer_id}", require(store.PermReviewsWriteAny)(adminHandler.RemoveReviewer))
+	r.Post("/admin/reviews/{id}/transitions", require(store.PermReviewsWriteAny)(idempotent(adminHandler.TransitionReview)))
+	r.Get("/admin/reviews/{id}/transitions", require(store.PermReviewsReadAny)(adminHandler.GetReviewTransitions))
+	r.Post("/admin/review-jobs", require(store.PermReviewsWriteAny)(idempotent(adminHandler.AddReviewJob)))
+	r.Get("/admin/review-jobs/{id}", require(store.PermReviewsReadAny)(adminHandler.GetReviewJob))
+
+	r.Post("/admin/cycles", require(store.PermCyclesManage)(idempotent(adminHandler.AddCycle)))
+	r.Get("/admin/cycles", require(store.PermCyclesManage)(adminHandler.GetCycles))
+	r.Get("/admin/cycles/{id}", require(store.PermCyclesManage)(adminHandler.GetCycle))
+	r.Put("/admin/cycles/{id}", require(store.PermCyclesManage)(adminHandler.UpdateCycle))
+	r.Delete("/admin/cycles/{id}", require(store.PermCyclesManage)(adminHandler.RemoveCycle))
+
+	r.Post("/admin/templates", require(store.PermTemplatesManage)(idempotent(adminHandler.AddTemplate)))
+	r.Get("/admin/templates", require(store.PermTemplatesManage)(adminHandler.GetTemplates))
+	r.Get("/admin/templates/{id}", require(store.PermTemplatesManage)(adminHandler.GetTemplate))
+	r.Delete("/admin/templates/{id}", require(store.PermTemplatesManage)(adminHandler.RemoveTemplate))
 
-	r.Route("/employee", func(r *router.Router) {
-		r.Use(middlewares.AuthEmployee)
-		r.Get("/reviews", handlers.ListReviews)
-		r.Post("/reviews/feedback", handlers.SubmitFeedback)
-	})
+	// Employee routes
+	r.Get("/employee/reviews", require(store.PermFeedbackWrite)(employeeHandler.ListReviews))
+	r.Post("/employee/reviews/feedback", require(store.PermFeedbackWrite)(idempotent(employeeHandler.SubmitFeedback)))
+	r.Get("/employee/reviews/received", require(store.PermReviewsReadOwn)(employeeHandler.ListReceivedReviews))
+	r.Get("/employee/reviews/{id}/feedback", require(store.PermFeedbackWrite)(employeeHandler.GetFeedback))
+	r.Put("/employee/reviews/{id}/feedback", require(store.PermFeedbackWrite)(employeeHandler.UpdateFeedback))
+	r.Delete("/employee/reviews/{id}/feedback", require(store.PermFeedbackWrite)(employeeHandler.RetractFeedback))
+	r.Put("/employee/reviews/{id}/feedback/draft", require(store.PermFeedbackWrite)(employeeHandler.SaveFeedbackDraft))
+	r.Post("/employee/reviews/{id}/feedback/submit", require(store.PermFeedbackWrite)(idempotent(employeeHandler.SubmitFeedbackDraft)))
+	r.Post("/employee/reviews/{id}/transitions", require(store.PermReviewsReadOwn)(idempotent(employeeHandler.AcknowledgeReview)))
+	r.Get("/employee/reviews/{id}/template", require(store.PermFeedbackWrite)(employeeHandler.GetReviewTemplate))
+
+	// Manager routes
+	r.Get("/manager/reports", require(store.PermReviewsReadOwnReports)(managerHandler.ListReports))
+	r.Get("/manager/reviews", require(store.PermReviewsReadOwnReports)(managerHandler.ListReviews))
+	r.Post("/manager/reviews", require(store.PermReviewsWriteOwnReports)(idempotent(managerHandler.AddReview)))
+	r.Put("/manager/reviews/{id}/comments", require(store.PermReviewsWriteOwnReports)(managerHandler.UpdateReview))
 
 	log.Println("Starting server on :8080...")
-	err := http.ListenAndServe(":8080", r)
+	err = http.ListenAndServe(":8080", r)
 	if err != nil {
 		return
 	}
 }
+
+// openOIDC connects to the OpenID Connect provider configured by OIDC_ISSUER,
+// returning nil when single sign-on is not configured. OIDC_PROVISION=true
+// creates employees for unknown emails on their first sign-in.
+func openOIDC(authHandler *handlers.AuthHandler) *handlers.OIDCHandler {
+	config, ok, err := oidc.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring OIDC: %v", err)
+	}
+	if !ok {
+		return nil
+	}
+
+	provider, err := oidc.Discover(context.Background(), config)
+	if err != nil {
+		log.Fatalf("Error connecting to OIDC provider: %v", err)
+	}
+	log.Printf("Single sign-on enabled with %s", config.Issuer)
+	return handlers.NewOIDCHandler(authHandler, provider, os.Getenv("OIDC_PROVISION") == "true")
+}
+
+// openStores selects the storage backend from STORE_BACKEND: "memory" keeps
+// everything in process memory, anything else uses Postgres at DATABASE_URL
+func openStores() store.Stores {
+	if os.Getenv("STORE_BACKEND") == "memory" {
+		log.Println("Using in-memory store")
+		return memory.New()
+	}
+
+	// Initialize database connection and bring the schema up to date
+	conn := db.Connect()
+	db.RunMigrations(conn)
+	return postgres.New(conn)
+}
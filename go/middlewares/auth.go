@@ -2,71 +2,182 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"go-api/handlers"
+	"go-api/keys"
+	"go-api/store"
 )
 
-type contextKey string
+// apiKeyTouchInterval limits how often the last use of an API key is written
+const apiKeyTouchInterval = time.Minute
 
-const userIDKey contextKey = "id"
+// errInvalidAPIKey is returned for unknown and expired API keys
+var errInvalidAPIKey = errors.New("invalid or expired API key")
 
-// AuthAdmin is middlewares that validates a JWT token and ensures the user has an admin role
-func AuthAdmin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenStr := r.Header.Get("Authorization")
-		if !strings.HasPrefix(tokenStr, "Bearer ") {
-			http.Error(w, "Unauthorized: missing or invalid token", http.StatusUnauthorized)
-			return
-		}
+// Authenticator validates access tokens against the keyring and the revocation
+// list, and personal API keys against the stored ones
+type Authenticator struct {
+	tokens    store.TokenStore
+	apiKeys   store.APIKeyStore
+	users     store.UserStore
+	employees store.EmployeeStore
+	roles     store.RoleStore
+	keys      *keys.Keyring
+}
 
-		tokenStr = strings.TrimPrefix(tokenStr, "Bearer ")
+// NewAuthenticator creates an Authenticator using the given stores, accepting tokens signed by keyring
+func NewAuthenticator(stores store.Stores, keyring *keys.Keyring) *Authenticator {
+	return &Authenticator{
+		tokens:    stores.Tokens,
+		apiKeys:   stores.APIKeys,
+		users:     stores.Users,
+		employees: stores.Employees,
+		roles:     stores.Roles,
+		keys:      keyring,
+	}
+}
 
-		claims := &handlers.Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return handlers.JwtKey, nil // Use the proper JwtKey from handlers
-	})
+// Require is middlewares that validates a JWT token or API key and ensures the user holds every given permission.
+// It wraps a single route, e.g. r.Get("/admin/employees", a.Require(store.PermEmployeesRead)(h.GetEmployees)).
+func (a *Authenticator) Require(permissions ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return a.require(true, permissions)
+}
 
-		if err != nil {
-			fmt.Printf("Error while parsing token: %v\n", err)
-		}
+// RequireLogin is Require for routes that manage the account itself, such as
+// its password and API keys, which API keys must not reach
+func (a *Authenticator) RequireLogin(permissions ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return a.require(false, permissions)
+}
+
+func (a *Authenticator) require(allowAPIKeys bool, permissions []string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			tokenStr := r.Header.Get("Authorization")
+			if !strings.HasPrefix(tokenStr, "Bearer ") {
+				handlers.WriteError(w, r, http.StatusUnauthorized, handlers.CodeUnauthorized, "Unauthorized: missing or invalid token")
+				return
+			}
+
+			tokenStr = strings.TrimPrefix(tokenStr, "Bearer ")
+
+			var claims *handlers.Claims
+			if strings.HasPrefix(tokenStr, handlers.APIKeyPrefix) {
+				if !allowAPIKeys {
+					handlers.WriteError(w, r, http.StatusForbidden, handlers.CodeAPIKeyNotAllowed, "Forbidden: API keys cannot be used here")
+					return
+				}
+				var err error
+				claims, err = a.apiKeyClaims(r.Context(), tokenStr)
+				if errors.Is(err, errInvalidAPIKey) {
+					handlers.WriteError(w, r, http.StatusUnauthorized, handlers.CodeAPIKeyInvalid, "Unauthorized: "+err.Error())
+					return
+				}
+				if err != nil {
+					log.Printf("Error checking API key: %v", err)
+					handlers.WriteError(w, r, http.StatusInternalServerError, handlers.CodeInternal, "Internal Server Error")
+					return
+				}
+			} else if claims = a.tokenClaims(w, r, tokenStr); claims == nil {
+				return
+			}
 
-		if !token.Valid || claims.Role != "admin" {
-			http.Error(w, "Forbidden: invalid token or insufficient privileges", http.StatusForbidden)
-			return
+			for _, permission := range permissions {
+				if !claims.HasPermission(permission) {
+					handlers.WriteError(w, r, http.StatusForbidden, handlers.CodeInsufficientPermissions, "Forbidden: insufficient privileges")
+					return
+				}
+			}
+
+			// Pass the claims to the request context
+			next.ServeHTTP(w, r.WithContext(handlers.WithClaims(r.Context(), claims)))
 		}
+	}
+}
+
+// tokenClaims verifies a JWT access token. It writes the error response and
+// returns nil when the token is refused.
+func (a *Authenticator) tokenClaims(w http.ResponseWriter, r *http.Request, tokenStr string) *handlers.Claims {
+	claims := &handlers.Claims{}
+	token, err := a.keys.Parse(tokenStr, claims)
+
+	if err != nil {
+		fmt.Printf("Error while parsing token: %v\n", err)
+	}
 
-		// Pass the email to the request context
-		// Maybe context not needed anymore
-		ctx := context.WithValue(r.Context(), userIDKey, claims.ID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	// Tokens without an ID predate revocation and cannot be revoked, so they are refused
+	if err != nil || !token.Valid || claims.Id == "" {
+		handlers.WriteError(w, r, http.StatusForbidden, handlers.CodeTokenInvalid, "Forbidden: invalid token")
+		return nil
+	}
+
+	revoked, err := a.tokens.IsRevoked(r.Context(), claims.Id)
+	if err != nil {
+		log.Printf("Error checking token revocation: %v", err)
+		handlers.WriteError(w, r, http.StatusInternalServerError, handlers.CodeInternal, "Internal Server Error")
+		return nil
+	}
+	if revoked {
+		handlers.WriteError(w, r, http.StatusUnauthorized, handlers.CodeTokenRevoked, "Unauthorized: token revoked")
+		return nil
+	}
+	return claims
 }
 
-// AuthEmployee is middlewares that validates a JWT token and ensures the user has an employee role
-func AuthEmployee(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenStr := r.Header.Get("Authorization")
-		if !strings.HasPrefix(tokenStr, "Bearer ") {
-			http.Error(w, "Unauthorized: missing or invalid token", http.StatusUnauthorized)
-			return
-		}
+// apiKeyClaims looks up a personal API key and returns claims for its user
+// with the key's permissions that the user's role still grants
+func (a *Authenticator) apiKeyClaims(ctx context.Context, apiKey string) (*handlers.Claims, error) {
+	key, err := a.apiKeys.GetByHash(ctx, handlers.HashAPIKey(apiKey))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(now) {
+		return nil, errInvalidAPIKey
+	}
 
-		tokenStr = strings.TrimPrefix(tokenStr, "Bearer ")
-		claims := &handlers.Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return handlers.JwtKey, nil
-	})
-		if err != nil || !token.Valid || claims.Role != "employee" {
-			http.Error(w, "Forbidden: invalid token or insufficient privileges", http.StatusForbidden)
-			return
+	user, err := a.users.Get(ctx, key.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	role, err := handlers.EffectiveRole(ctx, a.employees, user)
+	if err != nil {
+		return nil, err
+	}
+	granted, err := a.roles.Permissions(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if now.Sub(key.LastUsedAt) > apiKeyTouchInterval {
+		if err := a.apiKeys.Touch(ctx, key.ID, now); err != nil {
+			log.Printf("Error recording use of API key %d: %v", key.ID, err)
 		}
+	}
 
-		// Pass the email to the request context
-		ctx := context.WithValue(r.Context(), userIDKey, claims.ID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	permissions := []string{}
+	for _, permission := range key.Permissions {
+		if slices.Contains(granted, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return &handlers.Claims{
+		ID:          user.ID,
+		Email:       user.Email,
+		Role:        role,
+		Permissions: permissions,
+		APIKeyID:    key.ID,
+	}, nil
 }
@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go-api/handlers"
+	"go-api/store"
+)
+
+// DefaultIdempotencyTTL is how long responses are replayed unless IDEMPOTENCY_TTL says otherwise
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// maxIdempotencyKeyLength bounds idempotency keys accepted from clients
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with the body and replayed with it
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// Idempotency stores the response of a request sent with an Idempotency-Key
+// header and replays it when the request is retried with the same key, so a
+// retry after a dropped connection does not create the record twice
+type Idempotency struct {
+	records store.IdempotencyStore
+	ttl     time.Duration
+}
+
+// NewIdempotency creates an Idempotency using the given stores, replaying responses for ttl
+func NewIdempotency(stores store.Stores, ttl time.Duration) *Idempotency {
+	return &Idempotency{records: stores.Idempotency, ttl: ttl}
+}
+
+// IdempotencyTTLFromEnv reads IDEMPOTENCY_TTL, a duration such as 24h or 30m,
+// returning DefaultIdempotencyTTL when it is not set
+func IdempotencyTTLFromEnv() (time.Duration, error) {
+	value := os.Getenv("IDEMPOTENCY_TTL")
+	if value == "" {
+		return DefaultIdempotencyTTL, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, errors.New("IDEMPOTENCY_TTL must be a positive duration such as 24h")
+	}
+	return ttl, nil
+}
+
+// Wrap makes a single route idempotent. Keys belong to the caller, so wrap the
+// handler inside Require, e.g. r.Post("/admin/reviews", require(p)(idempotency.Wrap(h.AddReview))).
+// Requests without the header are handled as usual. A retry with the same
+// method, path, query and body gets the stored response with an Idempotent-Replayed
+// header; a different request with the same key is refused with 422. Server
+// errors are not stored, so retrying them runs the request again.
+func (i *Idempotency) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			handlers.WriteError(w, r, http.StatusBadRequest, handlers.CodeInvalidRequest, "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLength)+" characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			handlers.WriteError(w, r, http.StatusBadRequest, handlers.CodeInvalidRequest, "Invalid request payload")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Anonymous callers, such as those resetting a password, share user 0
+		var userID int
+		if claims, ok := handlers.ClaimsFromContext(r.Context()); ok {
+			userID = claims.ID
+		}
+		// The query is part of the request as it changes what some routes do, such as dry_run
+		hash := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n" + string(body)))
+		record := store.IdempotencyRecord{
+			Key:         strconv.Itoa(userID) + ":" + key,
+			RequestHash: hex.EncodeToString(hash[:]),
+			ExpiresAt:   time.Now().Add(i.ttl),
+		}
+
+		existing, claimed, err := i.records.Start(r.Context(), record)
+		if errors.Is(err, store.ErrNotFound) {
+			writeIdempotencyKeyInUse(w, r)
+			return
+		}
+		if err != nil {
+			log.Printf("Error claiming idempotency key: %v", err)
+			handlers.WriteError(w, r, http.StatusInternalServerError, handlers.CodeInternal, "Internal Server Error")
+			return
+		}
+		if !claimed {
+			replay(w, r, existing, record.RequestHash)
+			return
+		}
+
+		// Record the outcome even when the client hung up, which is when it retries
+		ctx := context.WithoutCancel(r.Context())
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		stored := false
+		defer func() {
+			// Free the key when the handler failed or panicked so a retry runs it again
+			if !stored {
+				if err := i.records.Release(ctx, record.Key); err != nil {
+					log.Printf("Error releasing idempotency key: %v", err)
+				}
+			}
+		}()
+		next(recorder, r)
+
+		if recorder.status >= http.StatusInternalServerError {
+			return
+		}
+		header := map[string]string{}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+		err = i.records.Finish(ctx, record.Key, recorder.status, header, recorder.body.Bytes())
+		if err != nil {
+			log.Printf("Error storing idempotent response: %v", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// replay writes the response stored for a key, or the error for a key that
+// was used with another request or whose first request is still running
+func replay(w http.ResponseWriter, r *http.Request, record store.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		handlers.WriteError(w, r, http.StatusUnprocessableEntity, handlers.CodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request")
+		return
+	}
+	if record.StatusCode == 0 {
+		writeIdempotencyKeyInUse(w, r)
+		return
+	}
+
+	for name, value := range record.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.StatusCode)
+	if _, err := w.Write(record.Body); err != nil {
+		log.Printf("Error writing replayed response: %v", err)
+	}
+}
+
+func writeIdempotencyKeyInUse(w http.ResponseWriter, r *http.Request) {
+	handlers.WriteError(w, r, http.StatusConflict, handlers.CodeIdempotencyKeyInUse, "A request with this Idempotency-Key is still being processed, retry it later")
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/handlers"
+	"go-api/store/memory"
+	"go-api/types"
+)
+
+func TestIdempotencyWrap(t *testing.T) {
+	type request struct {
+		target string
+		body   string
+	}
+	tests := []struct {
+		name string
+		// statuses are those the handler answers its calls with in turn
+		statuses     []int
+		requests     []request
+		wantStatus   int
+		wantCode     string
+		wantReplayed bool
+		wantCalls    int
+	}{
+		{
+			name:         "identical retry",
+			statuses:     []int{http.StatusCreated},
+			requests:     []request{{"/reviews", `{"a": 1}`}, {"/reviews", `{"a": 1}`}},
+			wantStatus:   http.StatusCreated,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:       "different body",
+			statuses:   []int{http.StatusCreated},
+			requests:   []request{{"/reviews", `{"a": 1}`}, {"/reviews", `{"a": 2}`}},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   handlers.CodeIdempotencyKeyReused,
+			wantCalls:  1,
+		},
+		{
+			name:       "different query",
+			statuses:   []int{http.StatusOK},
+			requests:   []request{{"/import?dry_run=true", `{"a": 1}`}, {"/import", `{"a": 1}`}},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   handlers.CodeIdempotencyKeyReused,
+			wantCalls:  1,
+		},
+		{
+			name:       "retry after a server error",
+			statuses:   []int{http.StatusInternalServerError, http.StatusCreated},
+			requests:   []request{{"/reviews", `{"a": 1}`}, {"/reviews", `{"a": 1}`}},
+			wantStatus: http.StatusCreated,
+			wantCalls:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			wrapped := NewIdempotency(memory.New(), time.Hour).Wrap(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[min(calls, len(tt.statuses)-1)]
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", `"1"`)
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"call": ` + strconv.Itoa(calls) + `}`))
+			})
+
+			var rec *httptest.ResponseRecorder
+			var first string
+			for i, sent := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, sent.target, strings.NewReader(sent.body))
+				req.Header.Set("Idempotency-Key", "key-1")
+				rec = httptest.NewRecorder()
+				wrapped(rec, req)
+				if i == 0 {
+					first = rec.Body.String()
+				}
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed {
+				if body := rec.Body.String(); body != first {
+					t.Errorf("replayed body = %s, want %s", body, first)
+				}
+				if etag := rec.Header().Get("ETag"); etag != `"1"` {
+					t.Errorf("replayed ETag = %q, want %q", etag, `"1"`)
+				}
+			}
+			if tt.wantCode != "" {
+				var problem types.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+					t.Fatalf("decoding error response: %v", err)
+				}
+				if problem.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", problem.Code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
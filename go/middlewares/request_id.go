@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"go-api/handlers"
+)
+
+// maxRequestIDLength bounds request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID, taken from the X-Request-ID header when
+// the client or a proxy set one, and echoes it in the response header
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" || len(id) > maxRequestIDLength {
+			b := make([]byte, 8)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(handlers.WithRequestID(r.Context(), id)))
+	})
+}
+
+// Recoverer turns panics into a JSON 500 response, logging them with the stack
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				log.Printf("Panic serving request %s: %v\n%s", handlers.RequestIDFromContext(r.Context()), err, debug.Stack())
+				handlers.WriteError(w, r, http.StatusInternalServerError, handlers.CodeInternal, "Internal Server Error")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,232 @@
+// Package oidc is a minimal OpenID Connect relying party for the
+// authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api/keys"
+)
+
+// jwksRefreshInterval limits how often an unknown key ID refetches the provider's keys
+const jwksRefreshInterval = time.Minute
+
+// Config identifies this API as a client of the identity provider
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL must point at /auth/oidc/callback and be registered with the provider
+	RedirectURL string
+}
+
+// ConfigFromEnv reads OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET and
+// OIDC_REDIRECT_URL. It reports false when OIDC_ISSUER is not set.
+func ConfigFromEnv() (Config, bool, error) {
+	config := Config{
+		Issuer:       strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+	}
+	if config.Issuer == "" {
+		return Config{}, false, nil
+	}
+	if config.ClientID == "" || config.RedirectURL == "" {
+		return Config{}, false, errors.New("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER")
+	}
+	return config, true, nil
+}
+
+// Provider is an identity provider found through OpenID Connect discovery
+type Provider struct {
+	config Config
+	client *http.Client
+
+	authorizationEndpoint string
+	tokenEndpoint         string
+	jwksURI               string
+
+	mu          sync.Mutex
+	keys        *keys.Keyring
+	keysFetched time.Time
+}
+
+// Discover loads the provider's metadata from its issuer URL
+func Discover(ctx context.Context, config Config) (*Provider, error) {
+	p := &Provider{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+
+	var metadata struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+	if err := p.getJSON(ctx, config.Issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("discovering %s: %w", config.Issuer, err)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != config.Issuer {
+		return nil, fmt.Errorf("discovery returned issuer %s, expected %s", metadata.Issuer, config.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	p.authorizationEndpoint = metadata.AuthorizationEndpoint
+	p.tokenEndpoint = metadata.TokenEndpoint
+	p.jwksURI = metadata.JWKSURI
+	return p, nil
+}
+
+// AuthCodeURL returns the provider URL to send the browser to
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(p.authorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.authorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange trades an authorization code for the raw ID token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("token endpoint returned %s: %s %s", resp.Status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("token endpoint returned no id_token")
+	}
+	return body.IDToken, nil
+}
+
+// Verify checks the ID token's signature, issuer, audience, expiry and nonce
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (IDToken, error) {
+	keyring, err := p.keyring(ctx, false)
+	if err != nil {
+		return IDToken{}, err
+	}
+
+	var token IDToken
+	if _, err := keyring.Parse(rawIDToken, &token); err != nil {
+		// The provider may have rotated its keys since they were fetched
+		keyring, refreshErr := p.keyring(ctx, true)
+		if refreshErr != nil {
+			return IDToken{}, err
+		}
+		token = IDToken{}
+		if _, err := keyring.Parse(rawIDToken, &token); err != nil {
+			return IDToken{}, err
+		}
+	}
+
+	switch {
+	case strings.TrimSuffix(token.Issuer, "/") != p.config.Issuer:
+		return IDToken{}, fmt.Errorf("unexpected issuer %s", token.Issuer)
+	case !token.Audience.contains(p.config.ClientID):
+		return IDToken{}, errors.New("token was issued for another client")
+	case token.Nonce != nonce:
+		return IDToken{}, errors.New("nonce mismatch")
+	}
+	return token, nil
+}
+
+// keyring returns the provider's signing keys, fetching them when missing or
+// when refresh is set and they were not fetched recently
+func (p *Provider) keyring(ctx context.Context, refresh bool) (*keys.Keyring, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil && (!refresh || time.Since(p.keysFetched) < jwksRefreshInterval) {
+		if refresh {
+			return nil, errors.New("provider keys were fetched recently")
+		}
+		return p.keys, nil
+	}
+
+	var set keys.JWKS
+	if err := p.getJSON(ctx, p.jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching provider keys: %w", err)
+	}
+	keyring, err := keys.FromJWKS(set)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keyring
+	p.keysFetched = time.Now()
+	return keyring, nil
+}
+
+// getJSON fetches and decodes a JSON document
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// CodeChallenge derives the S256 PKCE challenge sent with the authorization request
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+// IDToken holds the ID token claims used to sign users in
+type IDToken struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	Nonce     string   `json:"nonce"`
+	Email     string   `json:"email"`
+	// EmailVerified is nil when the provider does not send the claim
+	EmailVerified *bool `json:"email_verified"`
+}
+
+// Valid implements jwt.Claims, rejecting expired tokens
+func (t *IDToken) Valid() error {
+	if t.ExpiresAt == 0 || time.Now().Unix() >= t.ExpiresAt {
+		return errors.New("ID token is expired")
+	}
+	return nil
+}
+
+// audience is the aud claim, which is either one string or a list
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	return slices.Contains(a, clientID)
+}
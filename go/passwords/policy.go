@@ -0,0 +1,103 @@
+// Package passwords decides which passwords users may choose.
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMinLength is the shortest password accepted unless configured otherwise
+const DefaultMinLength = 12
+
+// maxLength keeps passwords within what bcrypt hashes; longer input is truncated by it
+const maxLength = 72
+
+// Policy checks new passwords against a minimum length and a list of breached passwords
+type Policy struct {
+	MinLength int
+	// breached holds upper-case hex SHA-1 hashes of breached passwords
+	breached map[string]struct{}
+}
+
+// LoadPolicy builds the policy from PASSWORD_MIN_LENGTH and PASSWORD_BREACH_LIST,
+// the path of a local breach list (see LoadBreachList)
+func LoadPolicy() (*Policy, error) {
+	policy := &Policy{MinLength: DefaultMinLength}
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); value != "" {
+		minLength, err := strconv.Atoi(value)
+		if err != nil || minLength < 1 || minLength > maxLength {
+			return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be between 1 and %d", maxLength)
+		}
+		policy.MinLength = minLength
+	}
+	if path := os.Getenv("PASSWORD_BREACH_LIST"); path != "" {
+		if err := policy.LoadBreachList(path); err != nil {
+			return nil, fmt.Errorf("loading breach list: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+// LoadBreachList adds the passwords listed in the file, one per line. Lines may
+// hold the password itself or its SHA-1 hash in hex, optionally followed by
+// ":count" as in the Pwned Passwords downloads.
+func (p *Policy) LoadBreachList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	if p.breached == nil {
+		p.breached = map[string]struct{}{}
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1(hash) {
+			p.breached[strings.ToUpper(hash)] = struct{}{}
+			continue
+		}
+		p.breached[sha1Hex(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Check returns an error explaining why the password is not acceptable, worded
+// to follow the name of the field holding it like the validate messages
+func (p *Policy) Check(password string) error {
+	length := utf8.RuneCountInString(password)
+	switch {
+	case length < p.MinLength:
+		return fmt.Errorf("must be at least %d characters", p.MinLength)
+	case len(password) > maxLength:
+		return fmt.Errorf("must be at most %d bytes", maxLength)
+	}
+	if _, ok := p.breached[sha1Hex(password)]; ok {
+		return errors.New("appears in a list of breached passwords, choose another")
+	}
+	return nil
+}
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSHA1(s string) bool {
+	if len(s) != 2*sha1.Size {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
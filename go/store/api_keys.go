@@ -0,0 +1,37 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// APIKey is a long-lived personal token for scripts, acting as its user with
+// at most Permissions. Only a hash of the key is kept.
+type APIKey struct {
+	ID     int
+	UserID int
+	Name   string
+	// Prefix is the start of the key, shown so users can tell their keys apart
+	Prefix      string
+	KeyHash     string
+	Permissions []string
+	// ExpiresAt is zero for keys that do not expire
+	ExpiresAt time.Time
+	// LastUsedAt is zero until the key is first used
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+// APIKeyStore persists personal API keys
+type APIKeyStore interface {
+	// Create stores the key, assigning its ID
+	Create(ctx context.Context, key APIKey) (APIKey, error)
+	// List returns the keys of the user, oldest first
+	List(ctx context.Context, userID int) ([]APIKey, error)
+	// GetByHash returns ErrNotFound when no key has the hash
+	GetByHash(ctx context.Context, keyHash string) (APIKey, error)
+	// Touch records that the key was used at the given time
+	Touch(ctx context.Context, id int, usedAt time.Time) error
+	// Delete revokes a key of the user, returning ErrNotFound when the user has no such key
+	Delete(ctx context.Context, userID, id int) error
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is the stored outcome of a request sent with an
+// Idempotency-Key header, replayed when the request is retried
+type IdempotencyRecord struct {
+	// Key is the client's key prefixed with the caller it belongs to
+	Key string
+	// RequestHash identifies the method, path and body the key was first used with
+	RequestHash string
+	// StatusCode is zero while the first request is still being handled
+	StatusCode int
+	// Header holds the response headers replayed along with the body, by name
+	Header    map[string]string
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IdempotencyStore persists the responses of requests sent with an idempotency key
+type IdempotencyStore interface {
+	// Start claims record.Key for a new request, dropping expired records first.
+	// It returns the record and true when the key was free, or the record
+	// already holding the key and false. It returns ErrNotFound when that
+	// record was released while being read.
+	Start(ctx context.Context, record IdempotencyRecord) (IdempotencyRecord, bool, error)
+	// Finish stores the response of the request that claimed the key
+	Finish(ctx context.Context, key string, statusCode int, header map[string]string, body []byte) error
+	// Release frees the key so that the request can be retried
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,22 @@
+package store
+
+// EmployeeImport is one employee of a bulk import, added when the email is not
+// in use yet and updated otherwise
+type EmployeeImport struct {
+	Email    string
+	Position string
+	// ManagerEmail names the manager, who may be part of the import too. Empty
+	// keeps the current manager, or none for new employees.
+	ManagerEmail string
+	// PasswordHash is the password of a new employee's login account, empty for
+	// employees invited to choose one. Existing employees keep theirs.
+	PasswordHash string
+}
+
+// ImportedEmployee is an employee as stored by an import
+type ImportedEmployee struct {
+	Employee
+	Created bool
+	// Changed is false for existing employees that already had the position and manager
+	Changed bool
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+)
+
+// EmployeeSortFields are the fields employee listings can be sorted by
+var EmployeeSortFields = []string{"id", "email", "position", "created_at"}
+
+// ReviewSortFields are the fields review listings can be sorted by; email is
+// the reviewed employee's
+var ReviewSortFields = []string{"id", "cycle_id", "employee_id", "email", "status", "created_at"}
+
+// SortKey orders a listing by one field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// Page selects part of a listing. Items are ordered by Sort and then by ID,
+// so every item has a fixed position a later page can continue after.
+type Page struct {
+	// Limit is the most items returned; zero returns all of them
+	Limit int
+	Sort  []SortKey
+	// After continues the listing behind the item it was taken from; nil
+	// starts at the first item
+	After *Cursor
+}
+
+// Cursor is the position of an item in a sorted listing: its value for each
+// sort key, as returned by SortValue, and its ID
+type Cursor struct {
+	Values []any
+	ID     int
+}
+
+// EmployeeFilter narrows employee listings; zero values match everything
+type EmployeeFilter struct {
+	Position      string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// SortValue returns the employee's value for one of EmployeeSortFields
+func (e Employee) SortValue(field string) any {
+	switch field {
+	case "id":
+		return e.ID
+	case "email":
+		return e.Email
+	case "position":
+		return e.Position
+	case "created_at":
+		return e.CreatedAt
+	}
+	panic(fmt.Sprintf("store: unknown employee sort field %q", field))
+}
+
+// SortValue returns the review's value for one of ReviewSortFields
+func (r Review) SortValue(field string) any {
+	switch field {
+	case "id":
+		return r.ID
+	case "cycle_id":
+		return r.CycleID
+	case "employee_id":
+		return r.EmployeeID
+	case "email":
+		return r.EmployeeEmail
+	case "status":
+		return r.Status
+	case "created_at":
+		return r.CreatedAt
+	}
+	panic(fmt.Sprintf("store: unknown review sort field %q", field))
+}
+
+// CursorOf returns the position of an item in a listing sorted by keys
+func CursorOf(item interface{ SortValue(string) any }, keys []SortKey) *Cursor {
+	cursor := &Cursor{ID: item.SortValue("id").(int)}
+	for _, key := range keys {
+		cursor.Values = append(cursor.Values, item.SortValue(key.Field))
+	}
+	return cursor
+}
+
+// Compare orders two items of a listing sorted by keys, the way the Postgres
+// stores do with ORDER BY
+func (c Cursor) Compare(other Cursor, keys []SortKey) int {
+	for i, key := range keys {
+		order := compareValues(c.Values[i], other.Values[i])
+		if key.Desc {
+			order = -order
+		}
+		if order != 0 {
+			return order
+		}
+	}
+	return cmp.Compare(c.ID, other.ID)
+}
+
+// compareValues orders two values of the same sort field
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case int:
+		return cmp.Compare(a, b.(int))
+	case string:
+		return cmp.Compare(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	panic(fmt.Sprintf("store: cannot sort by %T", a))
+}
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// LoginThrottle tracks the consecutive failed logins of an account or client
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	// LockedUntil is when logins are allowed again; zero or past when not locked
+	LockedUntil time.Time
+}
+
+// Backoff returns how long to refuse logins after the given number of consecutive failures
+type Backoff func(failures int) time.Duration
+
+// LoginThrottleStore persists failed login attempts
+type LoginThrottleStore interface {
+	// Get returns the zero LoginThrottle for keys without failures
+	Get(ctx context.Context, key string) (LoginThrottle, error)
+	// List returns the keys locked at now
+	List(ctx context.Context, now time.Time) ([]LoginThrottle, error)
+	// RecordFailure counts a failed login at now and locks the key for as long
+	// as backoff says. Failures before forgetBefore are forgotten first.
+	RecordFailure(ctx context.Context, key string, now, forgetBefore time.Time, backoff Backoff) (LoginThrottle, error)
+	// Reset forgets the failures of the key, unlocking it
+	Reset(ctx context.Context, key string) error
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"go-api/store"
+)
+
+// APIKeyStore is the in-memory implementation of store.APIKeyStore
+type APIKeyStore struct {
+	data *data
+}
+
+func (s *APIKeyStore) Create(_ context.Context, key store.APIKey) (store.APIKey, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if _, ok := s.data.users[key.UserID]; !ok {
+		return store.APIKey{}, store.ErrNotFound
+	}
+	s.data.nextAPIKeyID++
+	key.ID = s.data.nextAPIKeyID
+	key.Permissions = slices.Clone(key.Permissions)
+	key.CreatedAt = time.Now().UTC()
+	s.data.apiKeys[key.ID] = key
+	return key, nil
+}
+
+func (s *APIKeyStore) List(_ context.Context, userID int) ([]store.APIKey, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	var keys []store.APIKey
+	for _, key := range s.data.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (s *APIKeyStore) GetByHash(_ context.Context, keyHash string) (store.APIKey, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	for _, key := range s.data.apiKeys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+	return store.APIKey{}, store.ErrNotFound
+}
+
+func (s *APIKeyStore) Touch(_ context.Context, id int, usedAt time.Time) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if key, ok := s.data.apiKeys[id]; ok {
+		key.LastUsedAt = usedAt.UTC()
+		s.data.apiKeys[id] = key
+	}
+	return nil
+}
+
+func (s *APIKeyStore) Delete(_ context.Context, userID, id int) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	key, ok := s.data.apiKeys[id]
+	if !ok || key.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.data.apiKeys, id)
+	return nil
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go-api/store"
+)
+
+// CycleStore is the in-memory implementation of store.CycleStore
+type CycleStore struct {
+	data *data
+}
+
+func (s *CycleStore) Create(_ context.Context, cycle store.Cycle) (store.Cycle, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	s.data.nextCycleID++
+	cycle.ID = s.data.nextCycleID
+	cycle.CreatedAt = time.Now().UTC()
+	s.data.cycles[cycle.ID] = cycle
+	return cycle, nil
+}
+
+func (s *CycleStore) Get(_ context.Context, id int) (store.Cycle, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	cycle, ok := s.data.cycles[id]
+	if !ok {
+		return store.Cycle{}, store.ErrNotFound
+	}
+	return cycle, nil
+}
+
+func (s *CycleStore) List(_ context.Context) ([]store.Cycle, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	cycles := make([]store.Cycle, 0, len(s.data.cycles))
+	for _, cycle := range s.data.cycles {
+		cycles = append(cycles, cycle)
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		if !cycles[i].StartsAt.Equal(cycles[j].StartsAt) {
+			return cycles[i].StartsAt.After(cycles[j].StartsAt)
+		}
+		return cycles[i].ID > cycles[j].ID
+	})
+	return cycles, nil
+}
+
+func (s *CycleStore) Update(_ context.Context, cycle store.Cycle) (store.Cycle, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	existing, ok := s.data.cycles[cycle.ID]
+	if !ok {
+		return store.Cycle{}, store.ErrNotFound
+	}
+	cycle.CreatedAt = existing.CreatedAt
+	s.data.cycles[cycle.ID] = cycle
+	return cycle, nil
+}
+
+func (s *CycleStore) Delete(_ context.Context, id int) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if _, ok := s.data.cycles[id]; !ok {
+		return store.ErrNotFound
+	}
+	for _, review := range s.data.reviews {
+		if review.CycleID == id {
+			return store.ErrCycleInUse
+		}
+	}
+	for jobID, job := range s.data.reviewJobs {
+		if job.CycleID == id {
+			delete(s.data.reviewJobs, jobID)
+		}
+	}
+	delete(s.data.cycles, id)
+	return nil
+}
@@ -0,0 +1,279 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"go-api/store"
+)
+
+// EmployeeStore is the in-memory implementation of store.EmployeeStore
+type EmployeeStore struct {
+	data *data
+}
+
+func (s *EmployeeStore) Create(_ context.Context, employee store.Employee, passwordHash string) (store.Employee, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if s.data.employeeEmailTaken(employee.Email, 0) {
+		return store.Employee{}, store.ErrEmailTaken
+	}
+	if _, err := s.data.createUser(employee.Email, passwordHash, "employee"); err != nil {
+		return store.Employee{}, err
+	}
+
+	s.data.nextEmployeeID++
+	employee.ID = s.data.nextEmployeeID
+	employee.ManagerID = 0
+	employee.Version = 1
+	employee.CreatedAt = time.Now().UTC()
+	s.data.employees[employee.ID] = employee
+	return employee, nil
+}
+
+func (s *EmployeeStore) Get(_ context.Context, id int) (store.Employee, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	employee, ok := s.data.employees[id]
+	if !ok {
+		return store.Employee{}, store.ErrNotFound
+	}
+	return employee, nil
+}
+
+func (s *EmployeeStore) List(_ context.Context, filter store.EmployeeFilter, page store.Page) ([]store.Employee, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	employees := make([]store.Employee, 0, len(s.data.employees))
+	for _, employee := range s.data.employees {
+		if (filter.Position == "" || employee.Position == filter.Position) &&
+			createdBetween(employee.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) {
+			employees = append(employees, employee)
+		}
+	}
+	return paginate(employees, page), nil
+}
+
+func (s *EmployeeStore) GetByEmail(_ context.Context, email string) (store.Employee, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	for _, employee := range s.data.employees {
+		if employee.Email == email {
+			return employee, nil
+		}
+	}
+	return store.Employee{}, store.ErrNotFound
+}
+
+func (s *EmployeeStore) Update(_ context.Context, employee store.Employee, expectedVersion int) (int, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	existing, ok := s.data.employees[employee.ID]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	if err := checkVersion(existing.Version, expectedVersion); err != nil {
+		return 0, err
+	}
+	if s.data.employeeEmailTaken(employee.Email, employee.ID) {
+		return 0, store.ErrEmailTaken
+	}
+	employee.ManagerID = existing.ManagerID
+	employee.Version = existing.Version + 1
+	employee.CreatedAt = existing.CreatedAt
+	s.data.employees[employee.ID] = employee
+	return employee.Version, nil
+}
+
+func (s *EmployeeStore) Delete(_ context.Context, id, expectedVersion int) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	employee, ok := s.data.employees[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if err := checkVersion(employee.Version, expectedVersion); err != nil {
+		return err
+	}
+	delete(s.data.employees, id)
+	s.data.deleteUser(employee.Email)
+
+	// Reports lose their manager like ON DELETE SET NULL on manager_id
+	for reportID, report := range s.data.employees {
+		if report.ManagerID == id {
+			report.ManagerID = 0
+			s.data.employees[reportID] = report
+		}
+	}
+
+	// Cascade like the foreign keys on reviews and review_reviewers
+	for reviewID, review := range s.data.reviews {
+		if review.EmployeeID == id {
+			s.data.deleteReview(reviewID)
+			continue
+		}
+		review.ReviewerIDs = without(review.ReviewerIDs, id)
+		s.data.reviews[reviewID] = review
+	}
+	for feedbackID, feedback := range s.data.feedback {
+		if feedback.ReviewerID == id {
+			delete(s.data.feedback, feedbackID)
+		}
+	}
+	return nil
+}
+
+func (s *EmployeeStore) SetManager(_ context.Context, id, managerID, expectedVersion int) (int, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	employee, ok := s.data.employees[id]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	if err := checkVersion(employee.Version, expectedVersion); err != nil {
+		return 0, err
+	}
+	if managerID != 0 {
+		if _, ok := s.data.employees[managerID]; !ok {
+			return 0, store.ErrNotFound
+		}
+		// Walk up from the new manager; reaching the employee means a cycle
+		for current := managerID; current != 0; current = s.data.employees[current].ManagerID {
+			if current == id {
+				return 0, store.ErrManagerCycle
+			}
+		}
+	}
+
+	employee.ManagerID = managerID
+	employee.Version++
+	s.data.employees[id] = employee
+	return employee.Version, nil
+}
+
+func (s *EmployeeStore) Reports(_ context.Context, managerID, depth int) ([]store.Report, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	var reports []store.Report
+	level := []int{managerID}
+	for current := 1; len(level) > 0 && (depth == 0 || current <= depth); current++ {
+		var next []int
+		for _, employee := range s.data.employees {
+			if employee.ManagerID != 0 && slices.Contains(level, employee.ManagerID) {
+				reports = append(reports, store.Report{Employee: employee, Depth: current})
+				next = append(next, employee.ID)
+			}
+		}
+		level = next
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Depth != reports[j].Depth {
+			return reports[i].Depth < reports[j].Depth
+		}
+		return reports[i].ID < reports[j].ID
+	})
+	return reports, nil
+}
+
+func (s *EmployeeStore) Import(_ context.Context, imports []store.EmployeeImport) ([]store.ImportedEmployee, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	// Check everything before writing so that a failed import changes nothing
+	ids := map[string]int{}
+	for _, employee := range s.data.employees {
+		ids[employee.Email] = employee.ID
+	}
+	managers := map[string]string{}
+	for _, employee := range s.data.employees {
+		if employee.ManagerID != 0 {
+			managers[employee.Email] = s.data.employees[employee.ManagerID].Email
+		}
+	}
+	importing := map[string]bool{}
+	for _, employee := range imports {
+		if _, ok := ids[employee.Email]; !ok && s.data.userEmailTaken(employee.Email) {
+			return nil, store.ErrEmailTaken
+		}
+		importing[employee.Email] = true
+		if employee.ManagerEmail != "" {
+			managers[employee.Email] = employee.ManagerEmail
+		}
+	}
+	for _, employee := range imports {
+		if employee.ManagerEmail == "" {
+			continue
+		}
+		if _, ok := ids[employee.ManagerEmail]; !ok && !importing[employee.ManagerEmail] {
+			return nil, store.ErrNotFound
+		}
+		// Walk up from the manager; coming back anywhere means a cycle
+		visited := map[string]bool{employee.Email: true}
+		for current := employee.ManagerEmail; current != ""; current = managers[current] {
+			if visited[current] {
+				return nil, store.ErrManagerCycle
+			}
+			visited[current] = true
+		}
+	}
+
+	imported := make([]store.ImportedEmployee, len(imports))
+	for i, employee := range imports {
+		if id, ok := ids[employee.Email]; ok {
+			imported[i] = store.ImportedEmployee{Employee: s.data.employees[id]}
+			continue
+		}
+		if _, err := s.data.createUser(employee.Email, employee.PasswordHash, "employee"); err != nil {
+			return nil, err
+		}
+		s.data.nextEmployeeID++
+		created := store.Employee{
+			ID:        s.data.nextEmployeeID,
+			Email:     employee.Email,
+			Position:  employee.Position,
+			Version:   1,
+			CreatedAt: time.Now().UTC(),
+		}
+		s.data.employees[created.ID] = created
+		ids[created.Email] = created.ID
+		imported[i] = store.ImportedEmployee{Employee: created, Created: true, Changed: true}
+	}
+
+	// Managers can be new employees, so they are set once everyone exists
+	for i, employee := range imports {
+		stored := s.data.employees[imported[i].ID]
+		managerID := stored.ManagerID
+		if employee.ManagerEmail != "" {
+			managerID = ids[employee.ManagerEmail]
+		}
+		if !imported[i].Created && (stored.Position != employee.Position || stored.ManagerID != managerID) {
+			stored.Position = employee.Position
+			stored.Version++
+			imported[i].Changed = true
+		}
+		stored.ManagerID = managerID
+		s.data.employees[stored.ID] = stored
+		imported[i].Employee = stored
+	}
+	return imported, nil
+}
+
+// employeeEmailTaken reports whether another employee uses the email; callers hold the lock
+func (d *data) employeeEmailTaken(email string, exceptID int) bool {
+	for _, employee := range d.employees {
+		if employee.Email == email && employee.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"go-api/store"
+)
+
+// FeedbackStore is the in-memory implementation of store.FeedbackStore
+type FeedbackStore struct {
+	data *data
+}
+
+func (s *FeedbackStore) Create(_ context.Context, feedback store.Feedback) (store.Feedback, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if _, ok := s.data.reviews[feedback.ReviewID]; !ok {
+		return store.Feedback{}, store.ErrNotFound
+	}
+	if _, ok := s.data.findFeedback(feedback.ReviewID, feedback.ReviewerID); ok {
+		return store.Feedback{}, store.ErrFeedbackExists
+	}
+
+	s.data.nextFeedbackID++
+	now := time.Now().UTC()
+	feedback.ID = s.data.nextFeedbackID
+	feedback.ReviewerEmail = s.data.employees[feedback.ReviewerID].Email
+	feedback.Answers = slices.Clone(feedback.Answers)
+	feedback.Version = 1
+	feedback.CreatedAt = now
+	feedback.UpdatedAt = now
+	s.data.feedback[feedback.ID] = feedback
+	return feedback, nil
+}
+
+func (s *FeedbackStore) Get(_ context.Context, reviewID, reviewerID int) (store.Feedback, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	feedback, ok := s.data.findFeedback(reviewID, reviewerID)
+	if !ok {
+		return store.Feedback{}, store.ErrNotFound
+	}
+	return feedback, nil
+}
+
+func (s *FeedbackStore) Update(_ context.Context, feedback store.Feedback, expectedVersion int) (store.Feedback, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	existing, ok := s.data.findFeedback(feedback.ReviewID, feedback.ReviewerID)
+	switch {
+	case !ok:
+		return store.Feedback{}, store.ErrNotFound
+	case existing.Submitted:
+		return store.Feedback{}, store.ErrFeedbackSubmitted
+	case existing.Version != expectedVersion:
+		return store.Feedback{}, store.ErrVersionMismatch
+	}
+	existing.Body = feedback.Body
+	existing.Submitted = true
+	existing.Answers = slices.Clone(feedback.Answers)
+	existing.Version++
+	existing.UpdatedAt = time.Now().UTC()
+	s.data.feedback[existing.ID] = existing
+	return existing, nil
+}
+
+func (s *FeedbackStore) SaveDraft(_ context.Context, feedback store.Feedback, expectedVersion int) (store.Feedback, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if _, ok := s.data.reviews[feedback.ReviewID]; !ok {
+		return store.Feedback{}, store.ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	existing, ok := s.data.findFeedback(feedback.ReviewID, feedback.ReviewerID)
+	switch {
+	case !ok:
+		if expectedVersion != 0 {
+			return store.Feedback{}, store.ErrVersionMismatch
+		}
+		s.data.nextFeedbackID++
+		existing = store.Feedback{
+			ID:            s.data.nextFeedbackID,
+			ReviewID:      feedback.ReviewID,
+			ReviewerID:    feedback.ReviewerID,
+			ReviewerEmail: s.data.employees[feedback.ReviewerID].Email,
+			CreatedAt:     now,
+		}
+	case existing.Submitted:
+		return store.Feedback{}, store.ErrFeedbackSubmitted
+	case existing.Version != expectedVersion:
+		return store.Feedback{}, store.ErrVersionMismatch
+	}
+
+	existing.Body = feedback.Body
+	existing.Answers = slices.Clone(feedback.Answers)
+	existing.Version++
+	existing.UpdatedAt = now
+	s.data.feedback[existing.ID] = existing
+	return existing, nil
+}
+
+func (s *FeedbackStore) Submit(_ context.Context, reviewID, reviewerID, expectedVersion int) (store.Feedback, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	existing, ok := s.data.findFeedback(reviewID, reviewerID)
+	switch {
+	case !ok:
+		return store.Feedback{}, store.ErrNotFound
+	case existing.Submitted:
+		return store.Feedback{}, store.ErrFeedbackSubmitted
+	case existing.Version != expectedVersion:
+		return store.Feedback{}, store.ErrVersionMismatch
+	}
+
+	existing.Submitted = true
+	existing.Version++
+	existing.UpdatedAt = time.Now().UTC()
+	s.data.feedback[existing.ID] = existing
+	return existing, nil
+}
+
+func (s *FeedbackStore) Delete(_ context.Context, reviewID, reviewerID int) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	existing, ok := s.data.findFeedback(reviewID, reviewerID)
+	switch {
+	case !ok:
+		return store.ErrNotFound
+	case existing.Submitted:
+		return store.ErrFeedbackSubmitted
+	}
+	delete(s.data.feedback, existing.ID)
+	return nil
+}
+
+func (s *FeedbackStore) ListForReviews(_ context.Context, reviewIDs []int) ([]store.Feedback, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	var feedback []store.Feedback
+	for _, item := range s.data.feedback {
+		if slices.Contains(reviewIDs, item.ReviewID) {
+			feedback = append(feedback, item)
+		}
+	}
+	sort.Slice(feedback, func(i, j int) bool {
+		if !feedback[i].CreatedAt.Equal(feedback[j].CreatedAt) {
+			return feedback[i].CreatedAt.Before(feedback[j].CreatedAt)
+		}
+		return feedback[i].ID < feedback[j].ID
+	})
+	return feedback, nil
+}
+
+// findFeedback looks up a reviewer's feedback on a review; callers hold the lock
+func (d *data) findFeedback(reviewID, reviewerID int) (store.Feedback, bool) {
+	for _, feedback := range d.feedback {
+		if feedback.ReviewID == reviewID && feedback.ReviewerID == reviewerID {
+			return feedback, true
+		}
+	}
+	return store.Feedback{}, false
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"go-api/store"
+)
+
+// IdempotencyStore is the in-memory implementation of store.IdempotencyStore
+type IdempotencyStore struct {
+	data *data
+}
+
+func (s *IdempotencyStore) Start(_ context.Context, record store.IdempotencyRecord) (store.IdempotencyRecord, bool, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	now := time.Now().UTC()
+	for key, existing := range s.data.idempotency {
+		if !existing.ExpiresAt.After(now) {
+			delete(s.data.idempotency, key)
+		}
+	}
+
+	if existing, ok := s.data.idempotency[record.Key]; ok {
+		existing.Header = maps.Clone(existing.Header)
+		existing.Body = slices.Clone(existing.Body)
+		return existing, false, nil
+	}
+	record.StatusCode = 0
+	record.CreatedAt = now
+	s.data.idempotency[record.Key] = record
+	return record, true, nil
+}
+
+func (s *IdempotencyStore) Finish(_ context.Context, key string, statusCode int, header map[string]string, body []byte) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	record, ok := s.data.idempotency[key]
+	if !ok {
+		return store.ErrNotFound
+	}
+	record.StatusCode = statusCode
+	record.Header = maps.Clone(header)
+	record.Body = slices.Clone(body)
+	s.data.idempotency[key] = record
+	return nil
+}
+
+func (s *IdempotencyStore) Release(_ context.Context, key string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	delete(s.data.idempotency, key)
+	return nil
+}
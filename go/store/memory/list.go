@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"slices"
+	"time"
+
+	"go-api/store"
+)
+
+// paginate sorts items the way page asks and returns the ones it selects
+func paginate[T interface{ SortValue(string) any }](items []T, page store.Page) []T {
+	cursors := make(map[int]*store.Cursor, len(items))
+	for _, item := range items {
+		cursors[item.SortValue("id").(int)] = store.CursorOf(item, page.Sort)
+	}
+	cursor := func(item T) store.Cursor { return *cursors[item.SortValue("id").(int)] }
+
+	slices.SortFunc(items, func(a, b T) int { return cursor(a).Compare(cursor(b), page.Sort) })
+	if page.After != nil {
+		items = slices.DeleteFunc(items, func(item T) bool { return cursor(item).Compare(*page.After, page.Sort) <= 0 })
+	}
+	if page.Limit > 0 && len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return items
+}
+
+// createdBetween reports whether createdAt lies strictly between after and
+// before, either of which is unbounded when zero
+func createdBetween(createdAt, after, before time.Time) bool {
+	return (after.IsZero() || createdAt.After(after)) && (before.IsZero() || createdAt.Before(before))
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go-api/store"
+)
+
+// LoginThrottleStore is the in-memory implementation of store.LoginThrottleStore
+type LoginThrottleStore struct {
+	data *data
+}
+
+func (s *LoginThrottleStore) Get(_ context.Context, key string) (store.LoginThrottle, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	return s.data.loginThrottles[key], nil
+}
+
+func (s *LoginThrottleStore) List(_ context.Context, now time.Time) ([]store.LoginThrottle, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	var throttles []store.LoginThrottle
+	for _, throttle := range s.data.loginThrottles {
+		if throttle.LockedUntil.After(now) {
+			throttles = append(throttles, throttle)
+		}
+	}
+	sort.Slice(throttles, func(i, j int) bool { return throttles[i].Key < throttles[j].Key })
+	return throttles, nil
+}
+
+func (s *LoginThrottleStore) RecordFailure(_ context.Context, key string, now, forgetBefore time.Time, backoff store.Backoff) (store.LoginThrottle, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	throttle, ok := s.data.loginThrottles[key]
+	if !ok || throttle.LastFailureAt.Before(forgetBefore) {
+		throttle = store.LoginThrottle{Key: key}
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	throttle.LockedUntil = now.Add(backoff(throttle.Failures))
+	s.data.loginThrottles[key] = throttle
+	return throttle, nil
+}
+
+func (s *LoginThrottleStore) Reset(_ context.Context, key string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	delete(s.data.loginThrottles, key)
+	return nil
+}
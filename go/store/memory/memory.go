@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"go-api/store"
+)
+
+// data holds every record of an in-memory backend behind a single lock so the
+// stores sharing it see a consistent view, like tables in one database
+type data struct {
+	mu sync.RWMutex
+
+	nextUserID     int
+	nextEmployeeID int
+	nextReviewID   int
+	nextCycleID    int
+	nextTransition int
+	nextFeedbackID int
+	nextTemplateID int
+	nextSectionID  int
+	nextQuestionID int
+	nextRefreshID  int
+	nextResetID    int
+	nextAPIKeyID   int
+	nextJobID      int
+
+	users     map[int]store.User
+	employees map[int]store.Employee
+	reviews   map[int]store.Review
+	cycles    map[int]store.Cycle
+	feedback  map[int]store.Feedback
+	templates map[int]store.Template
+	roles     map[string]store.Role
+	// refreshTokens is keyed by token hash
+	refreshTokens map[string]store.RefreshToken
+	// revokedTokens maps revoked access token IDs to their expiry
+	revokedTokens map[string]time.Time
+	// passwordResets is keyed by token hash
+	passwordResets map[string]store.PasswordReset
+	// totp holds authenticator enrolments by user ID
+	totp map[int]store.TOTP
+	// recoveryCodes maps user IDs to their recovery code hashes and whether each was used
+	recoveryCodes map[int]map[string]bool
+	// apiKeys holds personal API keys by ID
+	apiKeys map[int]store.APIKey
+	// loginThrottles holds failed logins by throttle key
+	loginThrottles map[string]store.LoginThrottle
+	// reviewJobs holds batches of reviews created together, by ID
+	reviewJobs map[int]store.ReviewJob
+	// idempotency holds the responses of requests sent with an idempotency key, by key
+	idempotency map[string]store.IdempotencyRecord
+	// transitions holds the status history of every review in insertion order
+	transitions []store.ReviewTransition
+}
+
+// New returns stores backed by process memory, useful for tests and local runs
+func New() store.Stores {
+	d := &data{
+		users:     map[int]store.User{},
+		employees: map[int]store.Employee{},
+		reviews:   map[int]store.Review{},
+		cycles:    map[int]store.Cycle{},
+		feedback:  map[int]store.Feedback{},
+		templates: map[int]store.Template{},
+		roles:     map[string]store.Role{},
+
+		refreshTokens: map[string]store.RefreshToken{},
+		revokedTokens: map[string]time.Time{},
+
+		passwordResets: map[string]store.PasswordReset{},
+		totp:           map[int]store.TOTP{},
+		recoveryCodes:  map[int]map[string]bool{},
+		apiKeys:        map[int]store.APIKey{},
+		loginThrottles: map[string]store.LoginThrottle{},
+		idempotency:    map[string]store.IdempotencyRecord{},
+		reviewJobs:     map[int]store.ReviewJob{},
+	}
+	for _, role := range store.DefaultRoles {
+		role.Permissions = slices.Clone(role.Permissions)
+		d.roles[role.Name] = role
+	}
+	return store.Stores{
+		Users:       &UserStore{data: d},
+		Employees:   &EmployeeStore{data: d},
+		Reviews:     &ReviewStore{data: d},
+		ReviewJobs:  &ReviewJobStore{data: d},
+		Cycles:      &CycleStore{data: d},
+		Feedback:    &FeedbackStore{data: d},
+		Templates:   &TemplateStore{data: d},
+		Roles:       &RoleStore{data: d},
+		Tokens:      &TokenStore{data: d},
+		Resets:      &PasswordResetStore{data: d},
+		MFA:         &MFAStore{data: d},
+		Throttles:   &LoginThrottleStore{data: d},
+		APIKeys:     &APIKeyStore{data: d},
+		Idempotency: &IdempotencyStore{data: d},
+	}
+}
+
+// checkVersion returns ErrVersionMismatch when the caller expects another
+// version than the record has; an expected version of 0 skips the check
+func checkVersion(version, expectedVersion int) error {
+	if expectedVersion != 0 && version != expectedVersion {
+		return store.ErrVersionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+
+	"go-api/store"
+)
+
+// MFAStore is the in-memory implementation of store.MFAStore
+type MFAStore struct {
+	data *data
+}
+
+func (s *MFAStore) GetTOTP(_ context.Context, userID int) (store.TOTP, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	if _, ok := s.data.users[userID]; !ok {
+		return store.TOTP{}, store.ErrNotFound
+	}
+	return s.data.totp[userID], nil
+}
+
+func (s *MFAStore) StartTOTP(_ context.Context, userID int, secret string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if _, ok := s.data.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	s.data.totp[userID] = store.TOTP{Secret: secret}
+	return nil
+}
+
+func (s *MFAStore) EnableTOTP(_ context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	enrolment, ok := s.data.totp[userID]
+	if !ok || enrolment.Secret == "" {
+		return store.ErrNotFound
+	}
+	enrolment.Enabled = true
+	enrolment.LastStep = step
+	s.data.totp[userID] = enrolment
+
+	codes := map[string]bool{}
+	for _, hash := range recoveryCodeHashes {
+		codes[hash] = false
+	}
+	s.data.recoveryCodes[userID] = codes
+	return nil
+}
+
+func (s *MFAStore) DisableTOTP(_ context.Context, userID int) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	delete(s.data.totp, userID)
+	delete(s.data.recoveryCodes, userID)
+	return nil
+}
+
+func (s *MFAStore) UseTOTPStep(_ context.Context, userID int, step int64) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	enrolment, ok := s.data.totp[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if step <= enrolment.LastStep {
+		return store.ErrCodeUsed
+	}
+	enrolment.LastStep = step
+	s.data.totp[userID] = enrolment
+	return nil
+}
+
+func (s *MFAStore) UseRecoveryCode(_ context.Context, userID int, codeHash string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	used, ok := s.data.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return store.ErrNotFound
+	}
+	s.data.recoveryCodes[userID][codeHash] = true
+	return nil
+}
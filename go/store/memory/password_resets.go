@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go-api/store"
+)
+
+// PasswordResetStore is the in-memory implementation of store.PasswordResetStore
+type PasswordResetStore struct {
+	data *data
+}
+
+func (s *PasswordResetStore) Create(_ context.Context, reset store.PasswordReset) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	s.data.nextResetID++
+	reset.ID = s.data.nextResetID
+	reset.CreatedAt = time.Now().UTC()
+	s.data.passwordResets[reset.TokenHash] = reset
+	return nil
+}
+
+func (s *PasswordResetStore) Consume(_ context.Context, tokenHash string) (store.PasswordReset, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	now := time.Now().UTC()
+	reset, ok := s.data.passwordResets[tokenHash]
+	if !ok || !reset.UsedAt.IsZero() || !reset.ExpiresAt.After(now) {
+		return store.PasswordReset{}, store.ErrNotFound
+	}
+
+	for hash, other := range s.data.passwordResets {
+		if other.UserID == reset.UserID && other.UsedAt.IsZero() {
+			other.UsedAt = now
+			s.data.passwordResets[hash] = other
+		}
+	}
+	reset.UsedAt = now
+	return reset, nil
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go-api/store"
+)
+
+// ReviewJobStore is the in-memory implementation of store.ReviewJobStore
+type ReviewJobStore struct {
+	data *data
+}
+
+func (s *ReviewJobStore) Create(_ context.Context, job store.ReviewJob) (store.ReviewJob, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	// Check every review before adding any so that a failure leaves nothing behind
+	reviews := make([]store.Review, len(job.Results))
+	for i, result := range job.Results {
+		if result.SkipReason != "" {
+			continue
+		}
+		review := store.Review{
+			CycleID:     job.CycleID,
+			TemplateID:  job.TemplateID,
+			EmployeeID:  result.EmployeeID,
+			ReviewerIDs: result.ReviewerIDs,
+		}
+		reviewerIDs, err := s.data.checkReview(review)
+		if err != nil {
+			return store.ReviewJob{}, err
+		}
+		review.ReviewerIDs = reviewerIDs
+		reviews[i] = review
+	}
+
+	results := make([]store.ReviewJobResult, len(job.Results))
+	for i, result := range job.Results {
+		result.ReviewerIDs = slices.Clone(result.ReviewerIDs)
+		if result.SkipReason == "" {
+			result.ReviewID = s.data.addReview(reviews[i])
+		}
+		results[i] = result
+	}
+
+	s.data.nextJobID++
+	job.ID = s.data.nextJobID
+	job.Results = results
+	job.CreatedAt = time.Now().UTC()
+	s.data.reviewJobs[job.ID] = job
+	return s.data.withCurrentResults(job), nil
+}
+
+func (s *ReviewJobStore) Get(_ context.Context, id int) (store.ReviewJob, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	job, ok := s.data.reviewJobs[id]
+	if !ok {
+		return store.ReviewJob{}, store.ErrNotFound
+	}
+	return s.data.withCurrentResults(job), nil
+}
+
+// withCurrentResults returns a copy of the job leaving out removed employees
+// and the IDs of removed reviews, as the foreign keys of the Postgres tables
+// do; callers hold the lock
+func (d *data) withCurrentResults(job store.ReviewJob) store.ReviewJob {
+	results := make([]store.ReviewJobResult, 0, len(job.Results))
+	for _, result := range job.Results {
+		if _, ok := d.employees[result.EmployeeID]; !ok {
+			continue
+		}
+		if _, ok := d.reviews[result.ReviewID]; !ok {
+			result.ReviewID = 0
+		}
+		result.ReviewerIDs = slices.Clone(result.ReviewerIDs)
+		results = append(results, result)
+	}
+	job.Results = results
+	return job
+}
@@ -0,0 +1,270 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"go-api/store"
+)
+
+// ReviewStore is the in-memory implementation of store.ReviewStore
+type ReviewStore struct {
+	data *data
+}
+
+func (s *ReviewStore) Create(_ context.Context, review store.Review) (int, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	reviewerIDs, err := s.data.checkReview(review)
+	if err != nil {
+		return 0, err
+	}
+	review.ReviewerIDs = reviewerIDs
+	return s.data.addReview(review), nil
+}
+
+// checkReview checks what a new review refers to, returning its reviewers
+// sorted; callers hold the lock
+func (d *data) checkReview(review store.Review) ([]int, error) {
+	if _, ok := d.cycles[review.CycleID]; !ok {
+		return nil, fmt.Errorf("review cycle %d: %w", review.CycleID, store.ErrInvalidReference)
+	}
+	if _, ok := d.employees[review.EmployeeID]; !ok {
+		return nil, fmt.Errorf("employee %d: %w", review.EmployeeID, store.ErrInvalidReference)
+	}
+	if _, ok := d.templates[review.TemplateID]; review.TemplateID != 0 && !ok {
+		return nil, fmt.Errorf("review template %d: %w", review.TemplateID, store.ErrInvalidReference)
+	}
+	return d.checkReviewers(review.ReviewerIDs)
+}
+
+// addReview stores a checked review in the draft status and returns its ID; callers hold the lock
+func (d *data) addReview(review store.Review) int {
+	d.nextReviewID++
+	review.ID = d.nextReviewID
+	review.Status = store.ReviewDraft
+	review.Version = 1
+	review.CreatedAt = time.Now().UTC()
+	d.reviews[review.ID] = review
+	return review.ID
+}
+
+func (s *ReviewStore) Get(_ context.Context, id int) (store.Review, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	review, ok := s.data.reviews[id]
+	if !ok {
+		return store.Review{}, store.ErrNotFound
+	}
+	return s.data.withEmployeeEmail(review), nil
+}
+
+func (s *ReviewStore) Update(_ context.Context, id int, performanceReview string, reviewerIDs []int, expectedVersion int) (int, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	review, ok := s.data.reviews[id]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	if err := checkVersion(review.Version, expectedVersion); err != nil {
+		return 0, err
+	}
+	reviewerIDs, err := s.data.checkReviewers(reviewerIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	review.PerformanceReview = performanceReview
+	review.ReviewerIDs = reviewerIDs
+	review.Version++
+	s.data.reviews[id] = review
+	return review.Version, nil
+}
+
+func (s *ReviewStore) AddReviewer(_ context.Context, reviewID, reviewerID, expectedVersion int) (int, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	review, ok := s.data.reviews[reviewID]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	if err := checkVersion(review.Version, expectedVersion); err != nil {
+		return 0, err
+	}
+	reviewerIDs, err := s.data.checkReviewers(append(slices.Clone(review.ReviewerIDs), reviewerID))
+	if err != nil {
+		return 0, err
+	}
+
+	review.ReviewerIDs = reviewerIDs
+	review.Version++
+	s.data.reviews[reviewID] = review
+	return review.Version, nil
+}
+
+func (s *ReviewStore) RemoveReviewer(_ context.Context, reviewID, reviewerID, expectedVersion int) (int, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	review, ok := s.data.reviews[reviewID]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	if err := checkVersion(review.Version, expectedVersion); err != nil {
+		return 0, err
+	}
+	if !slices.Contains(review.ReviewerIDs, reviewerID) {
+		return 0, store.ErrNotAReviewer
+	}
+
+	review.ReviewerIDs = without(review.ReviewerIDs, reviewerID)
+	review.Version++
+	s.data.reviews[reviewID] = review
+	return review.Version, nil
+}
+
+func (s *ReviewStore) List(_ context.Context, filter store.ReviewFilter, page store.Page) ([]store.Review, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	reviews := make([]store.Review, 0, len(s.data.reviews))
+	for _, review := range s.data.reviews {
+		if s.data.matchesFilter(review, filter) {
+			reviews = append(reviews, s.data.withEmployeeEmail(review))
+		}
+	}
+	return paginate(reviews, page), nil
+}
+
+func (s *ReviewStore) ListPending(_ context.Context, reviewerID int, filter store.ReviewFilter, page store.Page) ([]store.Review, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	var reviews []store.Review
+	for _, review := range s.data.reviews {
+		if review.Status != store.ReviewInProgress || !slices.Contains(review.ReviewerIDs, reviewerID) || !s.data.matchesFilter(review, filter) {
+			continue
+		}
+		if feedback, ok := s.data.findFeedback(review.ID, reviewerID); !ok || !feedback.Submitted {
+			reviews = append(reviews, s.data.withEmployeeEmail(review))
+		}
+	}
+	return paginate(reviews, page), nil
+}
+
+func (s *ReviewStore) IsReviewer(_ context.Context, reviewID, reviewerID int) (bool, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	review, ok := s.data.reviews[reviewID]
+	return ok && slices.Contains(review.ReviewerIDs, reviewerID), nil
+}
+
+// checkReviewers enforces the review_reviewers constraints and returns a copy of the IDs;
+// callers hold the lock
+func (d *data) checkReviewers(reviewerIDs []int) ([]int, error) {
+	checked := make([]int, 0, len(reviewerIDs))
+	for _, reviewerID := range reviewerIDs {
+		if _, ok := d.employees[reviewerID]; !ok {
+			return nil, fmt.Errorf("reviewer %d: %w", reviewerID, store.ErrInvalidReference)
+		}
+		if slices.Contains(checked, reviewerID) {
+			return nil, fmt.Errorf("reviewer %d assigned twice: %w", reviewerID, store.ErrConflict)
+		}
+		checked = append(checked, reviewerID)
+	}
+	sort.Ints(checked)
+	return checked, nil
+}
+
+// withEmployeeEmail returns a copy of the review joined with its employee; callers hold the lock
+func (d *data) withEmployeeEmail(review store.Review) store.Review {
+	review.EmployeeEmail = d.employees[review.EmployeeID].Email
+	review.ReviewerIDs = slices.Clone(review.ReviewerIDs)
+	return review
+}
+
+func (s *ReviewStore) Transition(_ context.Context, id int, from, to string, actorID int) (store.ReviewTransition, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	review, ok := s.data.reviews[id]
+	if !ok || review.Status != from {
+		return store.ReviewTransition{}, store.ErrStatusChanged
+	}
+	review.Status = to
+	review.Version++
+	s.data.reviews[id] = review
+
+	s.data.nextTransition++
+	transition := store.ReviewTransition{
+		ID:         s.data.nextTransition,
+		ReviewID:   id,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	s.data.transitions = append(s.data.transitions, transition)
+	return transition, nil
+}
+
+func (s *ReviewStore) ListTransitions(_ context.Context, id int) ([]store.ReviewTransition, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	var transitions []store.ReviewTransition
+	for _, transition := range s.data.transitions {
+		if transition.ReviewID == id {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions, nil
+}
+
+// deleteReview removes a review and the records that cascade from it; callers hold the lock
+func (d *data) deleteReview(id int) {
+	delete(d.reviews, id)
+	for feedbackID, feedback := range d.feedback {
+		if feedback.ReviewID == id {
+			delete(d.feedback, feedbackID)
+		}
+	}
+	d.transitions = slices.DeleteFunc(d.transitions, func(t store.ReviewTransition) bool {
+		return t.ReviewID == id
+	})
+}
+
+// matchesFilter reports whether the review is listed under filter; callers hold the lock
+func (d *data) matchesFilter(review store.Review, filter store.ReviewFilter) bool {
+	return (filter.CycleID == 0 || review.CycleID == filter.CycleID) &&
+		(filter.EmployeeID == 0 || review.EmployeeID == filter.EmployeeID) &&
+		(filter.Status == "" || review.Status == filter.Status) &&
+		(filter.EmployeeIDs == nil || slices.Contains(filter.EmployeeIDs, review.EmployeeID)) &&
+		(filter.ReviewerID == 0 || slices.Contains(review.ReviewerIDs, filter.ReviewerID)) &&
+		createdBetween(review.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) &&
+		(filter.HasFeedback == nil || d.hasSubmittedFeedback(review.ID) == *filter.HasFeedback)
+}
+
+// hasSubmittedFeedback reports whether any reviewer submitted feedback on the
+// review; callers hold the lock
+func (d *data) hasSubmittedFeedback(reviewID int) bool {
+	for _, feedback := range d.feedback {
+		if feedback.ReviewID == reviewID && feedback.Submitted {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns ids with every occurrence of id removed
+func without(ids []int, id int) []int {
+	return slices.DeleteFunc(slices.Clone(ids), func(v int) bool { return v == id })
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"go-api/store"
+)
+
+// RoleStore is the in-memory implementation of store.RoleStore
+type RoleStore struct {
+	data *data
+}
+
+func (s *RoleStore) List(_ context.Context) ([]store.Role, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	roles := make([]store.Role, 0, len(s.data.roles))
+	for _, role := range s.data.roles {
+		role.Permissions = slices.Clone(role.Permissions)
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (s *RoleStore) Get(_ context.Context, name string) (store.Role, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	role, ok := s.data.roles[name]
+	if !ok {
+		return store.Role{}, store.ErrUnknownRole
+	}
+	role.Permissions = slices.Clone(role.Permissions)
+	return role, nil
+}
+
+func (s *RoleStore) SetMFARequired(_ context.Context, name string, required bool) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	role, ok := s.data.roles[name]
+	if !ok {
+		return store.ErrUnknownRole
+	}
+	role.MFARequired = required
+	s.data.roles[name] = role
+	return nil
+}
+
+func (s *RoleStore) Permissions(_ context.Context, role string) ([]string, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	stored, ok := s.data.roles[role]
+	if !ok {
+		return nil, store.ErrUnknownRole
+	}
+	return slices.Clone(stored.Permissions), nil
+}
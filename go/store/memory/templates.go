@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"go-api/store"
+)
+
+// TemplateStore is the in-memory implementation of store.TemplateStore
+type TemplateStore struct {
+	data *data
+}
+
+func (s *TemplateStore) Create(_ context.Context, template store.Template) (store.Template, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	s.data.nextTemplateID++
+	template.ID = s.data.nextTemplateID
+	template.CreatedAt = time.Now().UTC()
+	template = cloneTemplate(template)
+	for i := range template.Sections {
+		s.data.nextSectionID++
+		template.Sections[i].ID = s.data.nextSectionID
+		for j := range template.Sections[i].Questions {
+			s.data.nextQuestionID++
+			template.Sections[i].Questions[j].ID = s.data.nextQuestionID
+		}
+	}
+	s.data.templates[template.ID] = template
+	return cloneTemplate(template), nil
+}
+
+func (s *TemplateStore) Get(_ context.Context, id int) (store.Template, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	template, ok := s.data.templates[id]
+	if !ok {
+		return store.Template{}, store.ErrNotFound
+	}
+	return cloneTemplate(template), nil
+}
+
+func (s *TemplateStore) List(_ context.Context) ([]store.Template, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	templates := make([]store.Template, 0, len(s.data.templates))
+	for _, template := range s.data.templates {
+		templates = append(templates, cloneTemplate(template))
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].ID < templates[j].ID
+	})
+	return templates, nil
+}
+
+func (s *TemplateStore) Delete(_ context.Context, id int) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if _, ok := s.data.templates[id]; !ok {
+		return store.ErrNotFound
+	}
+	for _, review := range s.data.reviews {
+		if review.TemplateID == id {
+			return store.ErrTemplateInUse
+		}
+	}
+	delete(s.data.templates, id)
+	return nil
+}
+
+// cloneTemplate deep-copies a template so callers cannot modify stored sections
+func cloneTemplate(template store.Template) store.Template {
+	sections := make([]store.TemplateSection, len(template.Sections))
+	for i, section := range template.Sections {
+		questions := make([]store.TemplateQuestion, len(section.Questions))
+		for j, question := range section.Questions {
+			question.Options = slices.Clone(question.Options)
+			questions[j] = question
+		}
+		section.Questions = questions
+		sections[i] = section
+	}
+	template.Sections = sections
+	return template
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go-api/store"
+)
+
+// TokenStore is the in-memory implementation of store.TokenStore
+type TokenStore struct {
+	data *data
+}
+
+func (s *TokenStore) Create(_ context.Context, token store.RefreshToken) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	s.data.insertRefreshToken(token)
+	return nil
+}
+
+func (s *TokenStore) Rotate(_ context.Context, oldHash string, next store.RefreshToken) (store.RefreshToken, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	old, ok := s.data.refreshTokens[oldHash]
+	if !ok {
+		return store.RefreshToken{}, store.ErrNotFound
+	}
+	now := time.Now().UTC()
+	if !old.RevokedAt.IsZero() {
+		return store.RefreshToken{}, store.ErrTokenRevoked
+	}
+	if !old.UsedAt.IsZero() {
+		s.data.revokeRefreshTokens(func(token store.RefreshToken) bool { return token.FamilyID == old.FamilyID })
+		return store.RefreshToken{}, store.ErrTokenReused
+	}
+	if !old.ExpiresAt.After(now) {
+		return store.RefreshToken{}, store.ErrTokenRevoked
+	}
+
+	old.UsedAt = now
+	s.data.refreshTokens[oldHash] = old
+
+	next.FamilyID = old.FamilyID
+	next.UserID = old.UserID
+	return s.data.insertRefreshToken(next), nil
+}
+
+func (s *TokenStore) RevokeFamily(_ context.Context, familyID string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	s.data.revokeRefreshTokens(func(token store.RefreshToken) bool { return token.FamilyID == familyID })
+	return nil
+}
+
+func (s *TokenStore) RevokeUser(_ context.Context, userID int) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	s.data.revokeRefreshTokens(func(token store.RefreshToken) bool { return token.UserID == userID })
+	return nil
+}
+
+func (s *TokenStore) IsRevoked(_ context.Context, jti string) (bool, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	_, revoked := s.data.revokedTokens[jti]
+	return revoked, nil
+}
+
+// insertRefreshToken stores a refresh token assigning its ID; callers hold the lock
+func (d *data) insertRefreshToken(token store.RefreshToken) store.RefreshToken {
+	d.nextRefreshID++
+	token.ID = d.nextRefreshID
+	token.CreatedAt = time.Now().UTC()
+	d.refreshTokens[token.TokenHash] = token
+	return token
+}
+
+// revokeRefreshTokens revokes the matching refresh tokens and the access tokens
+// issued with them that have not expired yet; callers hold the lock
+func (d *data) revokeRefreshTokens(match func(store.RefreshToken) bool) {
+	now := time.Now().UTC()
+	// Drop revocations nobody can present anymore
+	for jti, expiresAt := range d.revokedTokens {
+		if !expiresAt.After(now) {
+			delete(d.revokedTokens, jti)
+		}
+	}
+
+	for hash, token := range d.refreshTokens {
+		if !match(token) {
+			continue
+		}
+		if token.AccessExpiresAt.After(now) {
+			d.revokedTokens[token.AccessJTI] = token.AccessExpiresAt
+		}
+		if token.RevokedAt.IsZero() {
+			token.RevokedAt = now
+			d.refreshTokens[hash] = token
+		}
+	}
+}
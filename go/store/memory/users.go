@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+
+	"go-api/store"
+)
+
+// UserStore is the in-memory implementation of store.UserStore
+type UserStore struct {
+	data *data
+}
+
+func (s *UserStore) Create(_ context.Context, email, passwordHash, role string) (store.User, error) {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	return s.data.createUser(email, passwordHash, role)
+}
+
+func (s *UserStore) Get(_ context.Context, id int) (store.User, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	user, ok := s.data.users[id]
+	if !ok {
+		return store.User{}, store.ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *UserStore) GetByEmail(_ context.Context, email string) (store.User, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+
+	for _, user := range s.data.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return store.User{}, store.ErrNotFound
+}
+
+func (s *UserStore) SetPassword(_ context.Context, id int, passwordHash string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	user, ok := s.data.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	user.PasswordHash = passwordHash
+	s.data.users[id] = user
+	return nil
+}
+
+func (s *UserStore) SetRole(_ context.Context, email, role string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+
+	if _, ok := s.data.roles[role]; !ok {
+		return store.ErrUnknownRole
+	}
+	for id, user := range s.data.users {
+		if user.Email == email {
+			user.Role = role
+			s.data.users[id] = user
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+// deleteUser removes the account with the email, cascading like the foreign
+// keys on refresh_tokens, password_resets, api_keys and review_transitions; callers hold the lock
+func (d *data) deleteUser(email string) {
+	for id, user := range d.users {
+		if user.Email != email {
+			continue
+		}
+		delete(d.users, id)
+		for hash, token := range d.refreshTokens {
+			if token.UserID == id {
+				delete(d.refreshTokens, hash)
+			}
+		}
+		for hash, reset := range d.passwordResets {
+			if reset.UserID == id {
+				delete(d.passwordResets, hash)
+			}
+		}
+		for keyID, key := range d.apiKeys {
+			if key.UserID == id {
+				delete(d.apiKeys, keyID)
+			}
+		}
+		delete(d.totp, id)
+		delete(d.recoveryCodes, id)
+		for i, transition := range d.transitions {
+			if transition.ActorID == id {
+				d.transitions[i].ActorID = 0
+			}
+		}
+	}
+}
+
+// createUser inserts a user enforcing the unique email constraint; callers hold the lock
+func (d *data) createUser(email, passwordHash, role string) (store.User, error) {
+	if d.userEmailTaken(email) {
+		return store.User{}, store.ErrEmailTaken
+	}
+
+	d.nextUserID++
+	user := store.User{ID: d.nextUserID, Email: email, PasswordHash: passwordHash, Role: role}
+	d.users[user.ID] = user
+	return user, nil
+}
+
+// userEmailTaken reports whether an account uses the email; callers hold the lock
+func (d *data) userEmailTaken(email string) bool {
+	for _, user := range d.users {
+		if user.Email == email {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCodeUsed is returned when a TOTP code for a time step that was already used is presented again
+var ErrCodeUsed = errors.New("code already used")
+
+// TOTP is a user's authenticator enrolment
+type TOTP struct {
+	// Secret is empty when the user never started enrolling
+	Secret string
+	// Enabled is set once the user confirmed a code; until then the secret is pending
+	Enabled bool
+	// LastStep is the last time step a code was accepted for
+	LastStep int64
+}
+
+// MFAStore persists two-factor authentication enrolments and recovery codes
+type MFAStore interface {
+	// GetTOTP returns ErrNotFound when the user does not exist
+	GetTOTP(ctx context.Context, userID int) (TOTP, error)
+	// StartTOTP stores a pending secret, replacing any earlier pending one
+	StartTOTP(ctx context.Context, userID int, secret string) error
+	// EnableTOTP enables the pending secret, accepting the code of the step, and
+	// replaces the recovery codes
+	EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	// DisableTOTP removes the secret and the recovery codes
+	DisableTOTP(ctx context.Context, userID int) error
+	// UseTOTPStep records that a code of the step was accepted, returning
+	// ErrCodeUsed unless the step is later than the last one used
+	UseTOTPStep(ctx context.Context, userID int, step int64) error
+	// UseRecoveryCode uses up the recovery code with the hash, returning
+	// ErrNotFound when it is unknown or already used
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+}
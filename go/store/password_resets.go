@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// PasswordReset is a stored password reset token. Only a hash of the token is kept.
+type PasswordReset struct {
+	ID        int
+	TokenHash string
+	UserID    int
+	ExpiresAt time.Time
+	// UsedAt is zero until the token is used
+	UsedAt    time.Time
+	CreatedAt time.Time
+}
+
+// PasswordResetStore persists password reset tokens
+type PasswordResetStore interface {
+	Create(ctx context.Context, reset PasswordReset) error
+	// Consume uses the reset token with the hash, returning ErrNotFound when it
+	// is unknown, used or expired. Every other outstanding token of the user
+	// is used up with it.
+	Consume(ctx context.Context, tokenHash string) (PasswordReset, error)
+}
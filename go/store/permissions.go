@@ -0,0 +1,78 @@
+package store
+
+import "errors"
+
+// Permissions granted to roles. Each names a resource, an action and, where
+// access is limited, whose records it covers.
+const (
+	// PermEmployeesRead allows listing employees and their reporting lines
+	PermEmployeesRead = "employees:read"
+	// PermEmployeesManage allows adding, changing and removing employees and their roles
+	PermEmployeesManage = "employees:manage"
+	// PermReviewsReadAny allows viewing every review with its feedback
+	PermReviewsReadAny = "reviews:read:any"
+	// PermReviewsWriteAny allows creating, editing and moving any review through the workflow
+	PermReviewsWriteAny = "reviews:write:any"
+	// PermReviewsReadOwnReports allows viewing reviews of the employees reporting to you
+	PermReviewsReadOwnReports = "reviews:read:own_reports"
+	// PermReviewsWriteOwnReports allows creating and editing reviews of the employees reporting to you
+	PermReviewsWriteOwnReports = "reviews:write:own_reports"
+	// PermReviewsReadOwn allows viewing and acknowledging reviews about yourself once shared
+	PermReviewsReadOwn = "reviews:read:own"
+	// PermFeedbackWrite allows giving feedback on reviews you are assigned to
+	PermFeedbackWrite = "feedback:write"
+	// PermCyclesManage allows managing review cycles
+	PermCyclesManage = "cycles:manage"
+	// PermTemplatesManage allows managing review templates
+	PermTemplatesManage = "templates:manage"
+)
+
+// ErrUnknownRole is returned when assigning a role that does not exist
+var ErrUnknownRole = errors.New("unknown role")
+
+// Role is a named set of permissions given to users
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+	// MFARequired makes users with the role use two-factor authentication
+	MFARequired bool
+}
+
+// DefaultRoles are the roles every backend starts with. The Postgres backend
+// seeds the same roles in its migrations; keep both in sync.
+var DefaultRoles = []Role{
+	{
+		Name:        "admin",
+		Description: "Full access",
+		Permissions: []string{
+			PermEmployeesRead, PermEmployeesManage, PermReviewsReadAny, PermReviewsWriteAny,
+			PermReviewsReadOwnReports, PermReviewsWriteOwnReports, PermReviewsReadOwn,
+			PermFeedbackWrite, PermCyclesManage, PermTemplatesManage,
+		},
+	},
+	{
+		Name:        "employee",
+		Description: "Gives feedback and reads reviews about themselves",
+		Permissions: []string{PermFeedbackWrite, PermReviewsReadOwn},
+	},
+	{
+		Name:        "manager",
+		Description: "Employee who also reviews their direct and indirect reports",
+		Permissions: []string{
+			PermFeedbackWrite, PermReviewsReadOwn, PermReviewsReadOwnReports, PermReviewsWriteOwnReports,
+		},
+	},
+	{
+		Name:        "hr_partner",
+		Description: "Runs reviews for the whole company",
+		Permissions: []string{
+			PermEmployeesRead, PermReviewsReadAny, PermReviewsWriteAny, PermCyclesManage, PermTemplatesManage,
+		},
+	},
+	{
+		Name:        "auditor",
+		Description: "Read-only access to employees and reviews",
+		Permissions: []string{PermEmployeesRead, PermReviewsReadAny},
+	},
+}
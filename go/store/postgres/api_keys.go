@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-api/store"
+
+	"github.com/lib/pq"
+)
+
+// APIKeyStore is the Postgres implementation of store.APIKeyStore
+type APIKeyStore struct {
+	conn *sql.DB
+}
+
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, permissions, expires_at, last_used_at, created_at"
+
+func (s *APIKeyStore) Create(ctx context.Context, key store.APIKey) (store.APIKey, error) {
+	var expiresAt sql.NullTime
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: key.ExpiresAt.UTC(), Valid: true}
+	}
+	row := s.conn.QueryRowContext(ctx, `
+        INSERT INTO api_keys (user_id, name, prefix, key_hash, permissions, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING `+apiKeyColumns,
+		key.UserID, key.Name, key.Prefix, key.KeyHash, pq.StringArray(key.Permissions), expiresAt,
+	)
+	return scanAPIKey(row)
+}
+
+func (s *APIKeyStore) List(ctx context.Context, userID int) ([]store.APIKey, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var keys []store.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *APIKeyStore) GetByHash(ctx context.Context, keyHash string) (store.APIKey, error) {
+	row := s.conn.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", keyHash)
+	return scanAPIKey(row)
+}
+
+func (s *APIKeyStore) Touch(ctx context.Context, id int, usedAt time.Time) error {
+	_, err := s.conn.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", id, usedAt.UTC())
+	return err
+}
+
+func (s *APIKeyStore) Delete(ctx context.Context, userID, id int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (store.APIKey, error) {
+	var key store.APIKey
+	var permissions pq.StringArray
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &permissions,
+		&expiresAt, &lastUsedAt, &key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.APIKey{}, store.ErrNotFound
+	}
+	key.Permissions = permissions
+	key.ExpiresAt = expiresAt.Time
+	key.LastUsedAt = lastUsedAt.Time
+	return key, err
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-api/store"
+)
+
+// CycleStore is the Postgres implementation of store.CycleStore
+type CycleStore struct {
+	conn *sql.DB
+}
+
+const cycleColumns = "id, name, starts_at, self_review_deadline, peer_review_deadline, closes_at, status, created_at"
+
+func (s *CycleStore) Create(ctx context.Context, cycle store.Cycle) (store.Cycle, error) {
+	row := s.conn.QueryRowContext(ctx, `
+        INSERT INTO review_cycles (name, starts_at, self_review_deadline, peer_review_deadline, closes_at, status)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING `+cycleColumns,
+		cycle.Name, cycle.StartsAt.UTC(), cycle.SelfReviewDeadline.UTC(), cycle.PeerReviewDeadline.UTC(),
+		cycle.ClosesAt.UTC(), cycle.Status,
+	)
+	return scanCycle(row)
+}
+
+func (s *CycleStore) Get(ctx context.Context, id int) (store.Cycle, error) {
+	row := s.conn.QueryRowContext(ctx, "SELECT "+cycleColumns+" FROM review_cycles WHERE id = $1", id)
+	return scanCycle(row)
+}
+
+func (s *CycleStore) List(ctx context.Context) ([]store.Cycle, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT "+cycleColumns+" FROM review_cycles ORDER BY starts_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var cycles []store.Cycle
+	for rows.Next() {
+		cycle, err := scanCycle(rows)
+		if err != nil {
+			return nil, err
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles, rows.Err()
+}
+
+func (s *CycleStore) Update(ctx context.Context, cycle store.Cycle) (store.Cycle, error) {
+	row := s.conn.QueryRowContext(ctx, `
+        UPDATE review_cycles
+        SET name = $1, starts_at = $2, self_review_deadline = $3, peer_review_deadline = $4, closes_at = $5, status = $6
+        WHERE id = $7
+        RETURNING `+cycleColumns,
+		cycle.Name, cycle.StartsAt.UTC(), cycle.SelfReviewDeadline.UTC(), cycle.PeerReviewDeadline.UTC(),
+		cycle.ClosesAt.UTC(), cycle.Status, cycle.ID,
+	)
+	return scanCycle(row)
+}
+
+func (s *CycleStore) Delete(ctx context.Context, id int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// Lock the cycle so no review can be added to it while we check
+	var lockedID int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM review_cycles WHERE id = $1 FOR UPDATE", id).Scan(&lockedID)
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrNotFound
+		}
+		return err
+	}
+
+	var inUse bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM reviews WHERE cycle_id = $1)", id).Scan(&inUse)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if inUse {
+		_ = tx.Rollback()
+		return store.ErrCycleInUse
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM review_cycles WHERE id = $1", id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCycle(row rowScanner) (store.Cycle, error) {
+	var cycle store.Cycle
+	err := row.Scan(&cycle.ID, &cycle.Name, &cycle.StartsAt, &cycle.SelfReviewDeadline, &cycle.PeerReviewDeadline,
+		&cycle.ClosesAt, &cycle.Status, &cycle.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Cycle{}, store.ErrNotFound
+	}
+	return cycle, err
+}
@@ -0,0 +1,337 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-api/store"
+)
+
+// EmployeeStore is the Postgres implementation of store.EmployeeStore
+type EmployeeStore struct {
+	conn *sql.DB
+}
+
+func (s *EmployeeStore) Create(ctx context.Context, employee store.Employee, passwordHash string) (store.Employee, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Employee{}, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO employees (email, position) VALUES ($1, $2) RETURNING id, version, created_at",
+		employee.Email, employee.Position,
+	).Scan(&employee.ID, &employee.Version, &employee.CreatedAt)
+	if err != nil {
+		_ = tx.Rollback()
+		return store.Employee{}, emailTaken(err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO users (email, password, role) VALUES ($1, $2, 'employee')",
+		employee.Email, passwordHash,
+	)
+	if err != nil {
+		_ = tx.Rollback()
+		return store.Employee{}, emailTaken(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Employee{}, err
+	}
+	return employee, nil
+}
+
+// employeeColumns maps store.EmployeeSortFields to their columns
+var employeeColumns = map[string]string{
+	"id":         "id",
+	"email":      "email",
+	"position":   "position",
+	"created_at": "created_at",
+}
+
+func (s *EmployeeStore) List(ctx context.Context, filter store.EmployeeFilter, page store.Page) ([]store.Employee, error) {
+	var q listQuery
+	if filter.Position != "" {
+		q.where("position = ?", filter.Position)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		q.where("created_at > ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		q.where("created_at < ?", filter.CreatedBefore)
+	}
+	order := q.page(page, employeeColumns)
+
+	rows, err := s.conn.QueryContext(ctx,
+		"SELECT id, email, position, COALESCE(manager_id, 0), version, created_at FROM employees "+q.whereClause()+" "+order,
+		q.args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var employees []store.Employee
+	for rows.Next() {
+		var employee store.Employee
+		if err := rows.Scan(&employee.ID, &employee.Email, &employee.Position, &employee.ManagerID, &employee.Version, &employee.CreatedAt); err != nil {
+			return nil, err
+		}
+		employees = append(employees, employee)
+	}
+	return employees, rows.Err()
+}
+
+func (s *EmployeeStore) Get(ctx context.Context, id int) (store.Employee, error) {
+	employee := store.Employee{ID: id}
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT email, position, COALESCE(manager_id, 0), version, created_at FROM employees WHERE id = $1", id,
+	).Scan(&employee.Email, &employee.Position, &employee.ManagerID, &employee.Version, &employee.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Employee{}, store.ErrNotFound
+	}
+	return employee, err
+}
+
+func (s *EmployeeStore) GetByEmail(ctx context.Context, email string) (store.Employee, error) {
+	employee := store.Employee{Email: email}
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT id, position, COALESCE(manager_id, 0), version, created_at FROM employees WHERE email = $1", email,
+	).Scan(&employee.ID, &employee.Position, &employee.ManagerID, &employee.Version, &employee.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Employee{}, store.ErrNotFound
+	}
+	return employee, err
+}
+
+func (s *EmployeeStore) Update(ctx context.Context, employee store.Employee, expectedVersion int) (int, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockVersion(ctx, tx, "employees", employee.ID, expectedVersion); err != nil {
+		return 0, err
+	}
+	var version int
+	err = tx.QueryRowContext(ctx,
+		"UPDATE employees SET email = $1, position = $2, version = version + 1 WHERE id = $3 RETURNING version",
+		employee.Email, employee.Position, employee.ID,
+	).Scan(&version)
+	if err != nil {
+		return 0, emailTaken(err)
+	}
+	return version, tx.Commit()
+}
+
+func (s *EmployeeStore) Delete(ctx context.Context, id, expectedVersion int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockVersion(ctx, tx, "employees", id, expectedVersion); err != nil {
+		return err
+	}
+	var email string
+	err = tx.QueryRowContext(ctx, "DELETE FROM employees WHERE id = $1 RETURNING email", id).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// The login account goes with the employee; its refresh tokens cascade
+	if _, err := tx.ExecContext(ctx, "DELETE FROM users WHERE email = $1", email); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *EmployeeStore) SetManager(ctx context.Context, id, managerID, expectedVersion int) (int, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Serialize hierarchy changes so two concurrent assignments cannot form a cycle together
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", managerLockID); err != nil {
+		return 0, err
+	}
+	if err := lockVersion(ctx, tx, "employees", id, expectedVersion); err != nil {
+		return 0, err
+	}
+
+	if managerID != 0 {
+		// Walk up the reporting line from the new manager; finding the employee means a cycle
+		var found, managerExists bool
+		err := tx.QueryRowContext(ctx, `
+            WITH RECURSIVE chain AS (
+                SELECT id, manager_id FROM employees WHERE id = $1
+                UNION
+                SELECT e.id, e.manager_id FROM employees e JOIN chain c ON e.id = c.manager_id
+            )
+            SELECT EXISTS(SELECT 1 FROM chain WHERE id = $2), EXISTS(SELECT 1 FROM chain)
+        `, managerID, id).Scan(&found, &managerExists)
+		if err != nil {
+			return 0, err
+		}
+		if !managerExists {
+			return 0, store.ErrNotFound
+		}
+		if found {
+			return 0, store.ErrManagerCycle
+		}
+	}
+
+	var version int
+	err = tx.QueryRowContext(ctx,
+		"UPDATE employees SET manager_id = NULLIF($1, 0), version = version + 1 WHERE id = $2 RETURNING version",
+		managerID, id,
+	).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+func (s *EmployeeStore) Reports(ctx context.Context, managerID, depth int) ([]store.Report, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+        WITH RECURSIVE reports AS (
+            SELECT id, email, position, manager_id, 1 AS depth
+            FROM employees
+            WHERE manager_id = $1
+            UNION ALL
+            SELECT e.id, e.email, e.position, e.manager_id, r.depth + 1
+            FROM employees e
+            JOIN reports r ON e.manager_id = r.id
+            WHERE $2 = 0 OR r.depth < $2
+        )
+        SELECT id, email, position, manager_id, depth FROM reports ORDER BY depth, id
+    `, managerID, depth)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var reports []store.Report
+	for rows.Next() {
+		var report store.Report
+		if err := rows.Scan(&report.ID, &report.Email, &report.Position, &report.ManagerID, &report.Depth); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+func (s *EmployeeStore) Import(ctx context.Context, imports []store.EmployeeImport) ([]store.ImportedEmployee, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Serialize hierarchy changes like SetManager
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", managerLockID); err != nil {
+		return nil, err
+	}
+
+	imported := make([]store.ImportedEmployee, len(imports))
+	ids := make([]int, len(imports))
+	for i, employee := range imports {
+		existing := store.Employee{Email: employee.Email}
+		err := tx.QueryRowContext(ctx,
+			"SELECT id, position, COALESCE(manager_id, 0), version, created_at FROM employees WHERE email = $1 FOR UPDATE",
+			employee.Email,
+		).Scan(&existing.ID, &existing.Position, &existing.ManagerID, &existing.Version, &existing.CreatedAt)
+		if err == nil {
+			imported[i] = store.ImportedEmployee{Employee: existing}
+			ids[i] = existing.ID
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		created := store.Employee{Email: employee.Email, Position: employee.Position}
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO employees (email, position) VALUES ($1, $2) RETURNING id, version, created_at",
+			employee.Email, employee.Position,
+		).Scan(&created.ID, &created.Version, &created.CreatedAt)
+		if err != nil {
+			return nil, emailTaken(err)
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO users (email, password, role) VALUES ($1, $2, 'employee')",
+			employee.Email, employee.PasswordHash,
+		)
+		if err != nil {
+			return nil, emailTaken(err)
+		}
+		imported[i] = store.ImportedEmployee{Employee: created, Created: true, Changed: true}
+		ids[i] = created.ID
+	}
+
+	// Managers can be new employees, so they are set once everyone exists
+	for i, employee := range imports {
+		stored := &imported[i]
+		managerID := stored.ManagerID
+		if employee.ManagerEmail != "" {
+			err := tx.QueryRowContext(ctx, "SELECT id FROM employees WHERE email = $1", employee.ManagerEmail).Scan(&managerID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, store.ErrNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if stored.Created {
+			if managerID != 0 {
+				_, err := tx.ExecContext(ctx, "UPDATE employees SET manager_id = $1 WHERE id = $2", managerID, stored.ID)
+				if err != nil {
+					return nil, err
+				}
+			}
+			stored.ManagerID = managerID
+			continue
+		}
+		if stored.Position == employee.Position && stored.ManagerID == managerID {
+			continue
+		}
+		err := tx.QueryRowContext(ctx,
+			"UPDATE employees SET position = $1, manager_id = NULLIF($2, 0), version = version + 1 WHERE id = $3 RETURNING version",
+			employee.Position, managerID, stored.ID,
+		).Scan(&stored.Version)
+		if err != nil {
+			return nil, err
+		}
+		stored.Position, stored.ManagerID, stored.Changed = employee.Position, managerID, true
+	}
+
+	// Walk up the reporting line of every imported employee; reaching them again means a cycle
+	var cycle bool
+	err = tx.QueryRowContext(ctx, `
+        WITH RECURSIVE chain(start, manager_id) AS (
+            SELECT id, manager_id FROM employees WHERE id = ANY($1) AND manager_id IS NOT NULL
+            UNION
+            SELECT c.start, e.manager_id FROM employees e JOIN chain c ON e.id = c.manager_id
+            WHERE e.manager_id IS NOT NULL
+        )
+        SELECT EXISTS(SELECT 1 FROM chain WHERE start = manager_id)
+    `, int64Array(ids)).Scan(&cycle)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, store.ErrManagerCycle
+	}
+	return imported, tx.Commit()
+}
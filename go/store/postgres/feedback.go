@@ -0,0 +1,320 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-api/store"
+
+	"github.com/lib/pq"
+)
+
+// FeedbackStore is the Postgres implementation of store.FeedbackStore
+type FeedbackStore struct {
+	conn *sql.DB
+}
+
+const feedbackColumns = `f.id, f.review_id, COALESCE(f.reviewer_id, 0), COALESCE(e.email, ''), f.body, f.submitted, f.version, f.created_at, f.updated_at`
+
+func (s *FeedbackStore) Create(ctx context.Context, feedback store.Feedback) (store.Feedback, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO feedback (review_id, reviewer_id, body, submitted)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (review_id, reviewer_id) DO NOTHING
+        RETURNING id
+    `, feedback.ReviewID, feedback.ReviewerID, feedback.Body, feedback.Submitted).Scan(&feedback.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Feedback{}, store.ErrFeedbackExists
+	}
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	if err := replaceAnswers(ctx, tx, feedback.ID, feedback.Answers); err != nil {
+		return store.Feedback{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Feedback{}, err
+	}
+	return s.Get(ctx, feedback.ReviewID, feedback.ReviewerID)
+}
+
+func (s *FeedbackStore) Get(ctx context.Context, reviewID, reviewerID int) (store.Feedback, error) {
+	row := s.conn.QueryRowContext(ctx, `
+        SELECT `+feedbackColumns+`
+        FROM feedback f
+        LEFT JOIN employees e ON f.reviewer_id = e.id
+        WHERE f.review_id = $1 AND f.reviewer_id = $2
+    `, reviewID, reviewerID)
+	feedback, err := scanFeedback(row)
+	if err != nil {
+		return store.Feedback{}, err
+	}
+
+	items := []store.Feedback{feedback}
+	if err := s.loadAnswers(ctx, items); err != nil {
+		return store.Feedback{}, err
+	}
+	return items[0], nil
+}
+
+func (s *FeedbackStore) Update(ctx context.Context, feedback store.Feedback, expectedVersion int) (store.Feedback, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	feedbackID, version, submitted, err := lockFeedback(ctx, tx, feedback.ReviewID, feedback.ReviewerID)
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	if submitted {
+		return store.Feedback{}, store.ErrFeedbackSubmitted
+	}
+	if version != expectedVersion {
+		return store.Feedback{}, store.ErrVersionMismatch
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE feedback SET body = $1, submitted = TRUE, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2
+    `, feedback.Body, feedbackID)
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	feedback.ID = feedbackID
+	if err := replaceAnswers(ctx, tx, feedback.ID, feedback.Answers); err != nil {
+		return store.Feedback{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Feedback{}, err
+	}
+	return s.Get(ctx, feedback.ReviewID, feedback.ReviewerID)
+}
+
+func (s *FeedbackStore) SaveDraft(ctx context.Context, feedback store.Feedback, expectedVersion int) (store.Feedback, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	feedbackID, version, submitted, err := lockFeedback(ctx, tx, feedback.ReviewID, feedback.ReviewerID)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		if expectedVersion != 0 {
+			return store.Feedback{}, store.ErrVersionMismatch
+		}
+		// A concurrent first save wins the unique constraint and this one is stale
+		err = tx.QueryRowContext(ctx, `
+            INSERT INTO feedback (review_id, reviewer_id, body, submitted)
+            VALUES ($1, $2, $3, FALSE)
+            ON CONFLICT (review_id, reviewer_id) DO NOTHING
+            RETURNING id
+        `, feedback.ReviewID, feedback.ReviewerID, feedback.Body).Scan(&feedbackID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.Feedback{}, store.ErrVersionMismatch
+		}
+	case err != nil:
+		return store.Feedback{}, err
+	case submitted:
+		return store.Feedback{}, store.ErrFeedbackSubmitted
+	case version != expectedVersion:
+		return store.Feedback{}, store.ErrVersionMismatch
+	default:
+		_, err = tx.ExecContext(ctx, `
+            UPDATE feedback SET body = $1, version = version + 1, updated_at = CURRENT_TIMESTAMP
+            WHERE review_id = $2 AND reviewer_id = $3
+        `, feedback.Body, feedback.ReviewID, feedback.ReviewerID)
+	}
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	if err := replaceAnswers(ctx, tx, feedbackID, feedback.Answers); err != nil {
+		return store.Feedback{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Feedback{}, err
+	}
+	return s.Get(ctx, feedback.ReviewID, feedback.ReviewerID)
+}
+
+func (s *FeedbackStore) Submit(ctx context.Context, reviewID, reviewerID, expectedVersion int) (store.Feedback, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, version, submitted, err := lockFeedback(ctx, tx, reviewID, reviewerID)
+	if err != nil {
+		return store.Feedback{}, err
+	}
+	if submitted {
+		return store.Feedback{}, store.ErrFeedbackSubmitted
+	}
+	if version != expectedVersion {
+		return store.Feedback{}, store.ErrVersionMismatch
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE feedback SET submitted = TRUE, version = version + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE review_id = $1 AND reviewer_id = $2
+    `, reviewID, reviewerID); err != nil {
+		return store.Feedback{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Feedback{}, err
+	}
+	return s.Get(ctx, reviewID, reviewerID)
+}
+
+// lockFeedback reads the ID, version and submitted flag of a reviewer's feedback,
+// holding a row lock until the transaction ends
+func lockFeedback(ctx context.Context, tx *sql.Tx, reviewID, reviewerID int) (int, int, bool, error) {
+	var id, version int
+	var submitted bool
+	err := tx.QueryRowContext(ctx, `
+        SELECT id, version, submitted FROM feedback
+        WHERE review_id = $1 AND reviewer_id = $2
+        FOR UPDATE
+    `, reviewID, reviewerID).Scan(&id, &version, &submitted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, false, store.ErrNotFound
+	}
+	return id, version, submitted, err
+}
+
+// replaceAnswers stores the answers of a feedback record in place of any previous ones
+func replaceAnswers(ctx context.Context, tx *sql.Tx, feedbackID int, answers []store.Answer) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM feedback_answers WHERE feedback_id = $1", feedbackID); err != nil {
+		return err
+	}
+	for _, answer := range answers {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO feedback_answers (feedback_id, question_id, rating, choice, text)
+            VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), NULLIF($5, ''))
+        `, feedbackID, answer.QuestionID, answer.Rating, answer.Choice, answer.Text)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadAnswers fills in the answers of the given feedback records
+func (s *FeedbackStore) loadAnswers(ctx context.Context, feedback []store.Feedback) error {
+	if len(feedback) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, len(feedback))
+	byID := map[int]*store.Feedback{}
+	for i := range feedback {
+		ids[i] = int64(feedback[i].ID)
+		byID[feedback[i].ID] = &feedback[i]
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `
+        SELECT a.feedback_id, a.question_id, COALESCE(a.rating, 0), COALESCE(a.choice, ''), COALESCE(a.text, '')
+        FROM feedback_answers a
+        JOIN template_questions q ON a.question_id = q.id
+        JOIN template_sections s ON q.section_id = s.id
+        WHERE a.feedback_id = ANY($1)
+        ORDER BY a.feedback_id, s.position, q.position
+    `, ids)
+	if err != nil {
+		return err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var feedbackID int
+		var answer store.Answer
+		if err := rows.Scan(&feedbackID, &answer.QuestionID, &answer.Rating, &answer.Choice, &answer.Text); err != nil {
+			return err
+		}
+		item := byID[feedbackID]
+		item.Answers = append(item.Answers, answer)
+	}
+	return rows.Err()
+}
+
+func (s *FeedbackStore) Delete(ctx context.Context, reviewID, reviewerID int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	feedbackID, _, submitted, err := lockFeedback(ctx, tx, reviewID, reviewerID)
+	if err != nil {
+		return err
+	}
+	if submitted {
+		return store.ErrFeedbackSubmitted
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM feedback WHERE id = $1", feedbackID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *FeedbackStore) ListForReviews(ctx context.Context, reviewIDs []int) ([]store.Feedback, error) {
+	if len(reviewIDs) == 0 {
+		return nil, nil
+	}
+
+	ids := make(pq.Int64Array, len(reviewIDs))
+	for i, id := range reviewIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `
+        SELECT `+feedbackColumns+`
+        FROM feedback f
+        LEFT JOIN employees e ON f.reviewer_id = e.id
+        WHERE f.review_id = ANY($1)
+        ORDER BY f.created_at, f.id
+    `, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var feedback []store.Feedback
+	for rows.Next() {
+		item, err := scanFeedback(rows)
+		if err != nil {
+			return nil, err
+		}
+		feedback = append(feedback, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return feedback, s.loadAnswers(ctx, feedback)
+}
+
+func scanFeedback(row rowScanner) (store.Feedback, error) {
+	var feedback store.Feedback
+	err := row.Scan(&feedback.ID, &feedback.ReviewID, &feedback.ReviewerID, &feedback.ReviewerEmail,
+		&feedback.Body, &feedback.Submitted, &feedback.Version, &feedback.CreatedAt, &feedback.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Feedback{}, store.ErrNotFound
+	}
+	return feedback, err
+}
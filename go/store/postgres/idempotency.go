@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go-api/store"
+)
+
+// IdempotencyStore is the Postgres implementation of store.IdempotencyStore
+type IdempotencyStore struct {
+	conn *sql.DB
+}
+
+func (s *IdempotencyStore) Start(ctx context.Context, record store.IdempotencyRecord) (store.IdempotencyRecord, bool, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.IdempotencyRecord{}, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now); err != nil {
+		return store.IdempotencyRecord{}, false, err
+	}
+
+	// Claim the key unless a concurrent request did; the loser reads the winner's record
+	record.StatusCode = 0
+	record.CreatedAt = now
+	result, err := tx.ExecContext(ctx, `
+        INSERT INTO idempotency_keys (key, request_hash, created_at, expires_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (key) DO NOTHING
+    `, record.Key, record.RequestHash, now, record.ExpiresAt.UTC())
+	if err != nil {
+		return store.IdempotencyRecord{}, false, err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return store.IdempotencyRecord{}, false, err
+	}
+	if claimed == 1 {
+		return record, true, tx.Commit()
+	}
+
+	existing := store.IdempotencyRecord{Key: record.Key}
+	var headers []byte
+	err = tx.QueryRowContext(ctx, `
+        SELECT request_hash, status_code, headers, body, created_at, expires_at
+        FROM idempotency_keys WHERE key = $1
+    `, record.Key).Scan(&existing.RequestHash, &existing.StatusCode, &headers, &existing.Body, &existing.CreatedAt, &existing.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released meanwhile; the client can simply retry
+		return store.IdempotencyRecord{}, false, store.ErrNotFound
+	}
+	if err != nil {
+		return store.IdempotencyRecord{}, false, err
+	}
+	if err := json.Unmarshal(headers, &existing.Header); err != nil {
+		return store.IdempotencyRecord{}, false, err
+	}
+	return existing, false, tx.Commit()
+}
+
+func (s *IdempotencyStore) Finish(ctx context.Context, key string, statusCode int, header map[string]string, body []byte) error {
+	headers, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status_code = $1, headers = $2, body = $3 WHERE key = $4",
+		statusCode, headers, body, key,
+	)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.conn.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1", key)
+	return err
+}
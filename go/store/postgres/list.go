@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"go-api/store"
+)
+
+// listQuery collects the WHERE, ORDER BY and LIMIT clauses of a listing
+// together with their arguments
+type listQuery struct {
+	conditions []string
+	args       []any
+}
+
+// arg adds an argument and returns its placeholder
+func (q *listQuery) arg(value any) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// where adds a condition on one argument, which the condition refers to as ?
+func (q *listQuery) where(condition string, value any) {
+	q.conditions = append(q.conditions, strings.ReplaceAll(condition, "?", q.arg(value)))
+}
+
+// whereClause joins the conditions, returning "" when there are none
+func (q *listQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// page adds the condition that skips to page.After and returns the ORDER BY
+// and LIMIT clauses. columns maps the sort fields to the columns holding them,
+// including "id", which breaks ties.
+func (q *listQuery) page(page store.Page, columns map[string]string) string {
+	keys := slices.Concat(page.Sort, []store.SortKey{{Field: "id"}})
+
+	if page.After != nil {
+		values := append(slices.Clone(page.After.Values), page.After.ID)
+		placeholders := make([]string, len(values))
+		for i, value := range values {
+			placeholders[i] = q.arg(value)
+		}
+
+		// (a > $1) OR (a = $1 AND b < $2) OR ... with < for descending keys
+		var alternatives []string
+		for i, key := range keys {
+			var terms []string
+			for j := range i {
+				terms = append(terms, columns[keys[j].Field]+" = "+placeholders[j])
+			}
+			operator := " > "
+			if key.Desc {
+				operator = " < "
+			}
+			terms = append(terms, columns[key.Field]+operator+placeholders[i])
+			alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+		}
+		q.conditions = append(q.conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+
+	order := make([]string, len(keys))
+	for i, key := range keys {
+		order[i] = columns[key.Field]
+		if key.Desc {
+			order[i] += " DESC"
+		}
+	}
+	clauses := "ORDER BY " + strings.Join(order, ", ")
+	if page.Limit > 0 {
+		clauses += " LIMIT " + q.arg(page.Limit)
+	}
+	return clauses
+}
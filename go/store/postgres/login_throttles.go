@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-api/store"
+)
+
+// LoginThrottleStore is the Postgres implementation of store.LoginThrottleStore
+type LoginThrottleStore struct {
+	conn *sql.DB
+}
+
+func (s *LoginThrottleStore) Get(ctx context.Context, key string) (store.LoginThrottle, error) {
+	throttle := store.LoginThrottle{Key: key}
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT failures, last_failure_at, locked_until FROM login_throttles WHERE key = $1", key,
+	).Scan(&throttle.Failures, &throttle.LastFailureAt, &throttle.LockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.LoginThrottle{}, nil
+	}
+	return throttle, err
+}
+
+func (s *LoginThrottleStore) List(ctx context.Context, now time.Time) ([]store.LoginThrottle, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+        SELECT key, failures, last_failure_at, locked_until
+        FROM login_throttles
+        WHERE locked_until > $1
+        ORDER BY key
+    `, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var throttles []store.LoginThrottle
+	for rows.Next() {
+		var throttle store.LoginThrottle
+		if err := rows.Scan(&throttle.Key, &throttle.Failures, &throttle.LastFailureAt, &throttle.LockedUntil); err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, throttle)
+	}
+	return throttles, rows.Err()
+}
+
+func (s *LoginThrottleStore) RecordFailure(ctx context.Context, key string, now, forgetBefore time.Time, backoff store.Backoff) (store.LoginThrottle, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.LoginThrottle{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now = now.UTC()
+	// Count the failure atomically so concurrent attempts are all counted
+	throttle := store.LoginThrottle{Key: key, LastFailureAt: now}
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO login_throttles (key, failures, last_failure_at, locked_until)
+        VALUES ($1, 1, $2, $2)
+        ON CONFLICT (key) DO UPDATE SET
+            failures = CASE WHEN login_throttles.last_failure_at < $3 THEN 1 ELSE login_throttles.failures + 1 END,
+            last_failure_at = $2
+        RETURNING failures
+    `, key, now, forgetBefore.UTC()).Scan(&throttle.Failures)
+	if err != nil {
+		return store.LoginThrottle{}, err
+	}
+
+	throttle.LockedUntil = now.Add(backoff(throttle.Failures))
+	_, err = tx.ExecContext(ctx, "UPDATE login_throttles SET locked_until = $1 WHERE key = $2", throttle.LockedUntil, key)
+	if err != nil {
+		return store.LoginThrottle{}, err
+	}
+	return throttle, tx.Commit()
+}
+
+func (s *LoginThrottleStore) Reset(ctx context.Context, key string) error {
+	_, err := s.conn.ExecContext(ctx, "DELETE FROM login_throttles WHERE key = $1", key)
+	return err
+}
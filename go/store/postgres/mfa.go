@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-api/store"
+)
+
+// MFAStore is the Postgres implementation of store.MFAStore
+type MFAStore struct {
+	conn *sql.DB
+}
+
+func (s *MFAStore) GetTOTP(ctx context.Context, userID int) (store.TOTP, error) {
+	var enrolment store.TOTP
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT COALESCE(totp_secret, ''), totp_enabled, totp_last_step FROM users WHERE id = $1", userID,
+	).Scan(&enrolment.Secret, &enrolment.Enabled, &enrolment.LastStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.TOTP{}, store.ErrNotFound
+	}
+	return enrolment, err
+}
+
+func (s *MFAStore) StartTOTP(ctx context.Context, userID int, secret string) error {
+	return s.updateUser(ctx,
+		"UPDATE users SET totp_secret = $2, totp_enabled = FALSE, totp_last_step = 0 WHERE id = $1",
+		userID, secret,
+	)
+}
+
+func (s *MFAStore) EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE users SET totp_enabled = TRUE, totp_last_step = $2 WHERE id = $1 AND totp_secret IS NOT NULL",
+		userID, step,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	for _, hash := range recoveryCodeHashes {
+		_, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *MFAStore) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0 WHERE id = $1", userID,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *MFAStore) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	// The condition on the last step makes concurrent uses of one code race safely
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2", userID, step,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrCodeUsed
+	}
+	return nil
+}
+
+func (s *MFAStore) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	result, err := s.conn.ExecContext(ctx, `
+        UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+    `, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// updateUser runs an UPDATE of one user, returning ErrNotFound when no row matched
+func (s *MFAStore) updateUser(ctx context.Context, query string, args ...any) error {
+	result, err := s.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-api/store"
+)
+
+// PasswordResetStore is the Postgres implementation of store.PasswordResetStore
+type PasswordResetStore struct {
+	conn *sql.DB
+}
+
+func (s *PasswordResetStore) Create(ctx context.Context, reset store.PasswordReset) error {
+	_, err := s.conn.ExecContext(ctx,
+		"INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		reset.TokenHash, reset.UserID, reset.ExpiresAt.UTC(),
+	)
+	return err
+}
+
+func (s *PasswordResetStore) Consume(ctx context.Context, tokenHash string) (store.PasswordReset, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.PasswordReset{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Marking the token used in the same statement makes it single-use under concurrency
+	now := time.Now().UTC()
+	reset := store.PasswordReset{TokenHash: tokenHash, UsedAt: now}
+	err = tx.QueryRowContext(ctx, `
+        UPDATE password_resets SET used_at = $1
+        WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1
+        RETURNING id, user_id, expires_at, created_at
+    `, now, tokenHash).Scan(&reset.ID, &reset.UserID, &reset.ExpiresAt, &reset.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.PasswordReset{}, store.ErrNotFound
+	}
+	if err != nil {
+		return store.PasswordReset{}, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE password_resets SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL",
+		now, reset.UserID,
+	)
+	if err != nil {
+		return store.PasswordReset{}, err
+	}
+	return reset, tx.Commit()
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"go-api/store"
+
+	"github.com/lib/pq"
+)
+
+// managerLockID is the Postgres advisory lock key held while changing reporting lines
+const managerLockID int64 = 4_918_273_646
+
+// New returns stores backed by the given Postgres connection
+func New(conn *sql.DB) store.Stores {
+	return store.Stores{
+		Users:       &UserStore{conn: conn},
+		Employees:   &EmployeeStore{conn: conn},
+		Reviews:     &ReviewStore{conn: conn},
+		ReviewJobs:  &ReviewJobStore{conn: conn},
+		Cycles:      &CycleStore{conn: conn},
+		Feedback:    &FeedbackStore{conn: conn},
+		Templates:   &TemplateStore{conn: conn},
+		Roles:       &RoleStore{conn: conn},
+		Tokens:      &TokenStore{conn: conn},
+		Resets:      &PasswordResetStore{conn: conn},
+		MFA:         &MFAStore{conn: conn},
+		Throttles:   &LoginThrottleStore{conn: conn},
+		APIKeys:     &APIKeyStore{conn: conn},
+		Idempotency: &IdempotencyStore{conn: conn},
+	}
+}
+
+// closeRows closes a result set, logging any error
+func closeRows(rows *sql.Rows) {
+	if err := rows.Close(); err != nil {
+		log.Printf("Error closing rows: %v", err)
+	}
+}
+
+// lockVersion locks the row of table with the ID until the transaction ends and
+// checks its version, returning ErrNotFound or ErrVersionMismatch. An expected
+// version of 0 skips the check.
+func lockVersion(ctx context.Context, tx *sql.Tx, table string, id, expectedVersion int) error {
+	var version int
+	err := tx.QueryRowContext(ctx, "SELECT version FROM "+table+" WHERE id = $1 FOR UPDATE", id).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if expectedVersion != 0 && version != expectedVersion {
+		return store.ErrVersionMismatch
+	}
+	return nil
+}
+
+// Postgres error codes of constraint violations
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// constraintError turns unique and foreign key violations into
+// store.ErrConflict and store.ErrInvalidReference
+func constraintError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case uniqueViolation:
+		return fmt.Errorf("%s: %w", pqErr.Constraint, store.ErrConflict)
+	case foreignKeyViolation:
+		return fmt.Errorf("%s: %w", pqErr.Constraint, store.ErrInvalidReference)
+	}
+	return err
+}
+
+// emailTaken turns unique violations, which only the email columns of users
+// and employees can cause on insert or update there, into store.ErrEmailTaken
+func emailTaken(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return store.ErrEmailTaken
+	}
+	return err
+}
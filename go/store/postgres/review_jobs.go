@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-api/store"
+
+	"github.com/lib/pq"
+)
+
+// ReviewJobStore is the Postgres implementation of store.ReviewJobStore
+type ReviewJobStore struct {
+	conn *sql.DB
+}
+
+func (s *ReviewJobStore) Create(ctx context.Context, job store.ReviewJob) (store.ReviewJob, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.ReviewJob{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO review_jobs (cycle_id, template_id, strategy, created_by) VALUES ($1, NULLIF($2, 0), $3, NULLIF($4, 0)) RETURNING id, created_at",
+		job.CycleID, job.TemplateID, job.Strategy, job.CreatedBy,
+	).Scan(&job.ID, &job.CreatedAt)
+	if err != nil {
+		return store.ReviewJob{}, constraintError(err)
+	}
+
+	results := make([]store.ReviewJobResult, len(job.Results))
+	for i, result := range job.Results {
+		if result.SkipReason == "" {
+			result.ReviewID, err = insertReview(ctx, tx, store.Review{
+				CycleID:     job.CycleID,
+				TemplateID:  job.TemplateID,
+				EmployeeID:  result.EmployeeID,
+				ReviewerIDs: result.ReviewerIDs,
+			})
+			if err != nil {
+				return store.ReviewJob{}, err
+			}
+		}
+
+		reviewerIDs := int64Array(result.ReviewerIDs)
+		if reviewerIDs == nil {
+			reviewerIDs = pq.Int64Array{}
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO review_job_results (job_id, position, employee_id, review_id, reviewer_ids, skip_reason) VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6)",
+			job.ID, i, result.EmployeeID, result.ReviewID, reviewerIDs, result.SkipReason,
+		)
+		if err != nil {
+			return store.ReviewJob{}, constraintError(err)
+		}
+		results[i] = result
+	}
+	job.Results = results
+
+	return job, tx.Commit()
+}
+
+func (s *ReviewJobStore) Get(ctx context.Context, id int) (store.ReviewJob, error) {
+	var job store.ReviewJob
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT id, cycle_id, COALESCE(template_id, 0), strategy, COALESCE(created_by, 0), created_at FROM review_jobs WHERE id = $1",
+		id,
+	).Scan(&job.ID, &job.CycleID, &job.TemplateID, &job.Strategy, &job.CreatedBy, &job.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ReviewJob{}, store.ErrNotFound
+	}
+	if err != nil {
+		return store.ReviewJob{}, err
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT employee_id, COALESCE(review_id, 0), reviewer_ids, skip_reason
+		FROM review_job_results
+		WHERE job_id = $1
+		ORDER BY position
+	`, id)
+	if err != nil {
+		return store.ReviewJob{}, err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var result store.ReviewJobResult
+		var reviewerIDs pq.Int64Array
+		if err := rows.Scan(&result.EmployeeID, &result.ReviewID, &reviewerIDs, &result.SkipReason); err != nil {
+			return store.ReviewJob{}, err
+		}
+		for _, reviewerID := range reviewerIDs {
+			result.ReviewerIDs = append(result.ReviewerIDs, int(reviewerID))
+		}
+		job.Results = append(job.Results, result)
+	}
+	return job, rows.Err()
+}
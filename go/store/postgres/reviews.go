@@ -0,0 +1,403 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+
+	"go-api/store"
+
+	"github.com/lib/pq"
+)
+
+// ReviewStore is the Postgres implementation of store.ReviewStore
+type ReviewStore struct {
+	conn *sql.DB
+}
+
+func (s *ReviewStore) Create(ctx context.Context, review store.Review) (int, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	reviewID, err := insertReview(ctx, tx, review)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	return reviewID, tx.Commit()
+}
+
+// insertReview adds the review in the draft status along with its reviewers
+func insertReview(ctx context.Context, tx *sql.Tx, review store.Review) (int, error) {
+	// Insert the review into the database
+	var reviewID int
+	err := tx.QueryRowContext(ctx,
+		"INSERT INTO reviews (cycle_id, template_id, employee_id, performance_review, status) VALUES ($1, NULLIF($2, 0), $3, $4, $5) RETURNING id",
+		review.CycleID, review.TemplateID, review.EmployeeID, review.PerformanceReview, store.ReviewDraft,
+	).Scan(&reviewID)
+	if err != nil {
+		return 0, constraintError(err)
+	}
+
+	if err := insertReviewers(ctx, tx, reviewID, review.ReviewerIDs); err != nil {
+		return 0, err
+	}
+	return reviewID, nil
+}
+
+func (s *ReviewStore) Get(ctx context.Context, id int) (store.Review, error) {
+	rows, err := s.conn.QueryContext(ctx, reviewQuery+`
+		WHERE r.id = $1
+		GROUP BY r.id, e.email
+	`, id)
+	if err != nil {
+		return store.Review{}, err
+	}
+	reviews, err := scanReviews(rows)
+	if err != nil {
+		return store.Review{}, err
+	}
+	if len(reviews) == 0 {
+		return store.Review{}, store.ErrNotFound
+	}
+	return reviews[0], nil
+}
+
+func (s *ReviewStore) Update(ctx context.Context, id int, performanceReview string, reviewerIDs []int, expectedVersion int) (int, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockVersion(ctx, tx, "reviews", id, expectedVersion); err != nil {
+		return 0, err
+	}
+
+	// Update the performance review
+	var version int
+	err = tx.QueryRowContext(ctx,
+		"UPDATE reviews SET performance_review = $1, version = version + 1 WHERE id = $2 RETURNING version",
+		performanceReview, id,
+	).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	// Remove the reviewers who are no longer listed and add the new ones, leaving
+	// the rows of those who stay as they are
+	listed := int64Array(reviewerIDs)
+	if listed == nil {
+		listed = pq.Int64Array{}
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM review_reviewers WHERE review_id = $1 AND reviewer_id <> ALL($2)", id, listed)
+	if err != nil {
+		return 0, err
+	}
+	kept, err := reviewerIDsOf(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	added := slices.DeleteFunc(slices.Clone(reviewerIDs), func(reviewerID int) bool { return slices.Contains(kept, reviewerID) })
+	if err := insertReviewers(ctx, tx, id, added); err != nil {
+		return 0, err
+	}
+
+	return version, tx.Commit()
+}
+
+func (s *ReviewStore) AddReviewer(ctx context.Context, reviewID, reviewerID, expectedVersion int) (int, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockVersion(ctx, tx, "reviews", reviewID, expectedVersion); err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO review_reviewers (review_id, reviewer_id) VALUES ($1, $2)",
+		reviewID, reviewerID,
+	)
+	if err != nil {
+		return 0, constraintError(err)
+	}
+	return bumpReviewVersion(ctx, tx, reviewID)
+}
+
+func (s *ReviewStore) RemoveReviewer(ctx context.Context, reviewID, reviewerID, expectedVersion int) (int, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockVersion(ctx, tx, "reviews", reviewID, expectedVersion); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx,
+		"DELETE FROM review_reviewers WHERE review_id = $1 AND reviewer_id = $2",
+		reviewID, reviewerID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, store.ErrNotAReviewer
+	}
+	return bumpReviewVersion(ctx, tx, reviewID)
+}
+
+// bumpReviewVersion increases the version of the review after its reviewers
+// changed and commits the transaction
+func bumpReviewVersion(ctx context.Context, tx *sql.Tx, reviewID int) (int, error) {
+	var version int
+	err := tx.QueryRowContext(ctx, "UPDATE reviews SET version = version + 1 WHERE id = $1 RETURNING version", reviewID).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+func (s *ReviewStore) List(ctx context.Context, filter store.ReviewFilter, page store.Page) ([]store.Review, error) {
+	var q listQuery
+	filterReviews(&q, filter)
+	order := q.page(page, reviewColumns)
+
+	rows, err := s.conn.QueryContext(ctx, reviewQuery+`
+		`+q.whereClause()+`
+		GROUP BY r.id, e.email
+		`+order, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanReviews(rows)
+}
+
+func (s *ReviewStore) ListPending(ctx context.Context, reviewerID int, filter store.ReviewFilter, page store.Page) ([]store.Review, error) {
+	var q listQuery
+	q.where("r.status = ?", store.ReviewInProgress)
+	q.where("r.id IN (SELECT review_id FROM review_reviewers WHERE reviewer_id = ?)", reviewerID)
+	q.where("NOT EXISTS (SELECT 1 FROM feedback f WHERE f.review_id = r.id AND f.reviewer_id = ? AND f.submitted)", reviewerID)
+	filterReviews(&q, filter)
+	order := q.page(page, reviewColumns)
+
+	rows, err := s.conn.QueryContext(ctx, reviewQuery+`
+		`+q.whereClause()+`
+		GROUP BY r.id, e.email
+		`+order, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanReviews(rows)
+}
+
+func (s *ReviewStore) IsReviewer(ctx context.Context, reviewID, reviewerID int) (bool, error) {
+	var isReviewer bool
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM review_reviewers WHERE review_id = $1 AND reviewer_id = $2)",
+		reviewID, reviewerID,
+	).Scan(&isReviewer)
+	return isReviewer, err
+}
+
+func (s *ReviewStore) Transition(ctx context.Context, id int, from, to string, actorID int) (store.ReviewTransition, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.ReviewTransition{}, err
+	}
+
+	// Only move the review if nobody else changed its status in the meantime
+	result, err := tx.ExecContext(ctx,
+		"UPDATE reviews SET status = $1, version = version + 1 WHERE id = $2 AND status = $3",
+		to, id, from,
+	)
+	if err != nil {
+		_ = tx.Rollback()
+		return store.ReviewTransition{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return store.ReviewTransition{}, err
+	}
+	if affected == 0 {
+		_ = tx.Rollback()
+		return store.ReviewTransition{}, store.ErrStatusChanged
+	}
+
+	transition := store.ReviewTransition{ReviewID: id, FromStatus: from, ToStatus: to, ActorID: actorID}
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO review_transitions (review_id, from_status, to_status, actor_id)
+        VALUES ($1, $2, $3, NULLIF($4, 0))
+        RETURNING id, created_at
+    `, id, from, to, actorID).Scan(&transition.ID, &transition.CreatedAt)
+	if err != nil {
+		_ = tx.Rollback()
+		return store.ReviewTransition{}, err
+	}
+
+	return transition, tx.Commit()
+}
+
+func (s *ReviewStore) ListTransitions(ctx context.Context, id int) ([]store.ReviewTransition, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+        SELECT id, review_id, from_status, to_status, COALESCE(actor_id, 0), created_at
+        FROM review_transitions
+        WHERE review_id = $1
+        ORDER BY id
+    `, id)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var transitions []store.ReviewTransition
+	for rows.Next() {
+		var transition store.ReviewTransition
+		err := rows.Scan(&transition.ID, &transition.ReviewID, &transition.FromStatus, &transition.ToStatus,
+			&transition.ActorID, &transition.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, transition)
+	}
+	return transitions, rows.Err()
+}
+
+// reviewQuery selects reviews joined with their employee and reviewers; callers
+// append the WHERE and GROUP BY clauses
+const reviewQuery = `
+		SELECT r.id, r.cycle_id, COALESCE(r.template_id, 0), r.employee_id, e.email AS employee_email, r.performance_review, r.status, r.version, r.created_at,
+		       ARRAY_REMOVE(ARRAY_AGG(rr.reviewer_id ORDER BY rr.reviewer_id), NULL) AS reviewer_ids
+		FROM reviews r
+		JOIN employees e ON r.employee_id = e.id
+		LEFT JOIN review_reviewers rr ON r.id = rr.review_id`
+
+// reviewColumns maps store.ReviewSortFields to the reviewQuery columns holding them
+var reviewColumns = map[string]string{
+	"id":          "r.id",
+	"cycle_id":    "r.cycle_id",
+	"employee_id": "r.employee_id",
+	"email":       "e.email",
+	"status":      "r.status",
+	"created_at":  "r.created_at",
+}
+
+// filterReviews adds the conditions of filter on reviewQuery to q
+func filterReviews(q *listQuery, filter store.ReviewFilter) {
+	if filter.CycleID != 0 {
+		q.where("r.cycle_id = ?", filter.CycleID)
+	}
+	if filter.EmployeeID != 0 {
+		q.where("r.employee_id = ?", filter.EmployeeID)
+	}
+	if filter.Status != "" {
+		q.where("r.status = ?", filter.Status)
+	}
+	if filter.EmployeeIDs != nil {
+		q.where("r.employee_id = ANY(?)", int64Array(filter.EmployeeIDs))
+	}
+	if filter.ReviewerID != 0 {
+		q.where("r.id IN (SELECT review_id FROM review_reviewers WHERE reviewer_id = ?)", filter.ReviewerID)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		q.where("r.created_at > ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		q.where("r.created_at < ?", filter.CreatedBefore)
+	}
+	if filter.HasFeedback != nil {
+		q.where("EXISTS (SELECT 1 FROM feedback f WHERE f.review_id = r.id AND f.submitted) = ?", *filter.HasFeedback)
+	}
+}
+
+// scanReviews reads and closes rows selected with reviewQuery
+func scanReviews(rows *sql.Rows) ([]store.Review, error) {
+	defer closeRows(rows)
+
+	var reviews []store.Review
+	for rows.Next() {
+		var review store.Review
+		var reviewerIDs pq.Int64Array
+		err := rows.Scan(&review.ID, &review.CycleID, &review.TemplateID, &review.EmployeeID, &review.EmployeeEmail, &review.PerformanceReview,
+			&review.Status, &review.Version, &review.CreatedAt, &reviewerIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, reviewerID := range reviewerIDs {
+			review.ReviewerIDs = append(review.ReviewerIDs, int(reviewerID))
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+// int64Array converts IDs to a Postgres array parameter, keeping nil as NULL
+func int64Array(ids []int) pq.Int64Array {
+	if ids == nil {
+		return nil
+	}
+	array := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		array[i] = int64(id)
+	}
+	return array
+}
+
+// reviewerIDsOf returns the reviewers assigned to the review
+func reviewerIDsOf(ctx context.Context, tx *sql.Tx, reviewID int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT reviewer_id FROM review_reviewers WHERE review_id = $1", reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var reviewerIDs []int
+	for rows.Next() {
+		var reviewerID int
+		if err := rows.Scan(&reviewerID); err != nil {
+			return nil, err
+		}
+		reviewerIDs = append(reviewerIDs, reviewerID)
+	}
+	return reviewerIDs, rows.Err()
+}
+
+// insertReviewers adds reviewers to the review_reviewers table concurrently
+func insertReviewers(ctx context.Context, tx *sql.Tx, reviewID int, reviewerIDs []int) error {
+	errChan := make(chan error, len(reviewerIDs)) // Buffered channel for errors
+	var wg sync.WaitGroup
+
+	for _, reviewerID := range reviewerIDs {
+		wg.Go(func() {
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO review_reviewers (review_id, reviewer_id) VALUES ($1, $2)",
+				reviewID, reviewerID,
+			)
+			errChan <- constraintError(err)
+		})
+	}
+
+	// Wait for all goroutines to finish
+	wg.Wait()
+	close(errChan)
+
+	// Check for errors from the goroutines
+	for err := range errChan {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-api/store"
+
+	"github.com/lib/pq"
+)
+
+// RoleStore is the Postgres implementation of store.RoleStore
+type RoleStore struct {
+	conn *sql.DB
+}
+
+func (s *RoleStore) List(ctx context.Context) ([]store.Role, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+        SELECT r.name, r.description, r.mfa_required,
+               ARRAY_REMOVE(ARRAY_AGG(p.permission ORDER BY p.permission), NULL) AS permissions
+        FROM roles r
+        LEFT JOIN role_permissions p ON r.name = p.role
+        GROUP BY r.name
+        ORDER BY r.name
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var roles []store.Role
+	for rows.Next() {
+		var role store.Role
+		var permissions pq.StringArray
+		if err := rows.Scan(&role.Name, &role.Description, &role.MFARequired, &permissions); err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (s *RoleStore) Get(ctx context.Context, name string) (store.Role, error) {
+	role := store.Role{Name: name}
+	var permissions pq.StringArray
+	err := s.conn.QueryRowContext(ctx, `
+        SELECT description, mfa_required,
+               ARRAY(SELECT permission FROM role_permissions WHERE role = $1 ORDER BY permission)
+        FROM roles
+        WHERE name = $1
+    `, name).Scan(&role.Description, &role.MFARequired, &permissions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Role{}, store.ErrUnknownRole
+	}
+	if err != nil {
+		return store.Role{}, err
+	}
+	role.Permissions = permissions
+	return role, nil
+}
+
+func (s *RoleStore) SetMFARequired(ctx context.Context, name string, required bool) error {
+	result, err := s.conn.ExecContext(ctx, "UPDATE roles SET mfa_required = $1 WHERE name = $2", required, name)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrUnknownRole
+	}
+	return nil
+}
+
+func (s *RoleStore) Permissions(ctx context.Context, role string) ([]string, error) {
+	var exists bool
+	var permissions pq.StringArray
+	err := s.conn.QueryRowContext(ctx, `
+        SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1),
+               ARRAY(SELECT permission FROM role_permissions WHERE role = $1 ORDER BY permission)
+    `, role).Scan(&exists, &permissions)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, store.ErrUnknownRole
+	}
+	return permissions, nil
+}
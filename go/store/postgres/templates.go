@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-api/store"
+
+	"github.com/lib/pq"
+)
+
+// TemplateStore is the Postgres implementation of store.TemplateStore
+type TemplateStore struct {
+	conn *sql.DB
+}
+
+func (s *TemplateStore) Create(ctx context.Context, template store.Template) (store.Template, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Template{}, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO review_templates (name, description) VALUES ($1, $2) RETURNING id, created_at",
+		template.Name, template.Description,
+	).Scan(&template.ID, &template.CreatedAt)
+	if err != nil {
+		_ = tx.Rollback()
+		return store.Template{}, err
+	}
+
+	// Sections and questions keep the order they were given in
+	sections := make([]store.TemplateSection, len(template.Sections))
+	for i, section := range template.Sections {
+		err := tx.QueryRowContext(ctx,
+			"INSERT INTO template_sections (template_id, position, title) VALUES ($1, $2, $3) RETURNING id",
+			template.ID, i, section.Title,
+		).Scan(&section.ID)
+		if err != nil {
+			_ = tx.Rollback()
+			return store.Template{}, err
+		}
+
+		questions := make([]store.TemplateQuestion, len(section.Questions))
+		for j, question := range section.Questions {
+			err := tx.QueryRowContext(ctx, `
+                INSERT INTO template_questions (section_id, position, prompt, type, required, options)
+                VALUES ($1, $2, $3, $4, $5, $6)
+                RETURNING id
+            `, section.ID, j, question.Prompt, question.Type, question.Required, pq.StringArray(question.Options)).Scan(&question.ID)
+			if err != nil {
+				_ = tx.Rollback()
+				return store.Template{}, err
+			}
+			questions[j] = question
+		}
+		section.Questions = questions
+		sections[i] = section
+	}
+	template.Sections = sections
+
+	return template, tx.Commit()
+}
+
+func (s *TemplateStore) Get(ctx context.Context, id int) (store.Template, error) {
+	var template store.Template
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT id, name, description, created_at FROM review_templates WHERE id = $1", id,
+	).Scan(&template.ID, &template.Name, &template.Description, &template.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Template{}, store.ErrNotFound
+	}
+	if err != nil {
+		return store.Template{}, err
+	}
+
+	templates := []store.Template{template}
+	if err := s.loadSections(ctx, templates); err != nil {
+		return store.Template{}, err
+	}
+	return templates[0], nil
+}
+
+func (s *TemplateStore) List(ctx context.Context) ([]store.Template, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT id, name, description, created_at FROM review_templates ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var templates []store.Template
+	for rows.Next() {
+		var template store.Template
+		if err := rows.Scan(&template.ID, &template.Name, &template.Description, &template.CreatedAt); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, s.loadSections(ctx, templates)
+}
+
+func (s *TemplateStore) Delete(ctx context.Context, id int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// Lock the template so no review can be created from it while we check
+	var lockedID int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM review_templates WHERE id = $1 FOR UPDATE", id).Scan(&lockedID)
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrNotFound
+		}
+		return err
+	}
+
+	var inUse bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM reviews WHERE template_id = $1)", id).Scan(&inUse)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if inUse {
+		_ = tx.Rollback()
+		return store.ErrTemplateInUse
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM review_templates WHERE id = $1", id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// loadSections fills in the sections and questions of the given templates
+func (s *TemplateStore) loadSections(ctx context.Context, templates []store.Template) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, len(templates))
+	byID := map[int]*store.Template{}
+	for i := range templates {
+		ids[i] = int64(templates[i].ID)
+		byID[templates[i].ID] = &templates[i]
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `
+        SELECT s.template_id, s.id, s.title, q.id, q.prompt, q.type, q.required, q.options
+        FROM template_sections s
+        JOIN template_questions q ON q.section_id = s.id
+        WHERE s.template_id = ANY($1)
+        ORDER BY s.template_id, s.position, q.position
+    `, ids)
+	if err != nil {
+		return err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var templateID int
+		var section store.TemplateSection
+		var question store.TemplateQuestion
+		var options pq.StringArray
+		err := rows.Scan(&templateID, &section.ID, &section.Title,
+			&question.ID, &question.Prompt, &question.Type, &question.Required, &options)
+		if err != nil {
+			return err
+		}
+		if len(options) > 0 {
+			question.Options = options
+		}
+
+		template := byID[templateID]
+		if n := len(template.Sections); n == 0 || template.Sections[n-1].ID != section.ID {
+			template.Sections = append(template.Sections, section)
+		}
+		last := &template.Sections[len(template.Sections)-1]
+		last.Questions = append(last.Questions, question)
+	}
+	return rows.Err()
+}
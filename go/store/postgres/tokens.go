@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-api/store"
+)
+
+// TokenStore is the Postgres implementation of store.TokenStore
+type TokenStore struct {
+	conn *sql.DB
+}
+
+func (s *TokenStore) Create(ctx context.Context, token store.RefreshToken) error {
+	_, err := insertRefreshToken(ctx, s.conn, token)
+	return err
+}
+
+func (s *TokenStore) Rotate(ctx context.Context, oldHash string, next store.RefreshToken) (store.RefreshToken, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return store.RefreshToken{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Lock the token so two concurrent refreshes cannot both rotate it
+	var old store.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+        SELECT id, family_id, user_id, expires_at, used_at, revoked_at
+        FROM refresh_tokens
+        WHERE token_hash = $1
+        FOR UPDATE
+    `, oldHash).Scan(&old.ID, &old.FamilyID, &old.UserID, &old.ExpiresAt, &usedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.RefreshToken{}, store.ErrNotFound
+	}
+	if err != nil {
+		return store.RefreshToken{}, err
+	}
+
+	now := time.Now().UTC()
+	if revokedAt.Valid {
+		return store.RefreshToken{}, store.ErrTokenRevoked
+	}
+	if usedAt.Valid {
+		// A rotated token came back: someone holds a copy, so end the whole session
+		if err := revokeRefreshTokens(ctx, tx, "family_id = $2", old.FamilyID); err != nil {
+			return store.RefreshToken{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return store.RefreshToken{}, err
+		}
+		return store.RefreshToken{}, store.ErrTokenReused
+	}
+	if !old.ExpiresAt.After(now) {
+		return store.RefreshToken{}, store.ErrTokenRevoked
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = $1 WHERE id = $2", now, old.ID); err != nil {
+		return store.RefreshToken{}, err
+	}
+
+	next.FamilyID = old.FamilyID
+	next.UserID = old.UserID
+	next, err = insertRefreshToken(ctx, tx, next)
+	if err != nil {
+		return store.RefreshToken{}, err
+	}
+	return next, tx.Commit()
+}
+
+func (s *TokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.revoke(ctx, "family_id = $2", familyID)
+}
+
+func (s *TokenStore) RevokeUser(ctx context.Context, userID int) error {
+	return s.revoke(ctx, "user_id = $2", userID)
+}
+
+func (s *TokenStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti,
+	).Scan(&revoked)
+	return revoked, err
+}
+
+// revoke runs revokeRefreshTokens in its own transaction
+func (s *TokenStore) revoke(ctx context.Context, condition string, arg any) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := revokeRefreshTokens(ctx, tx, condition, arg); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revokeRefreshTokens revokes the refresh tokens matching condition, which
+// refers to arg as $2, and adds the access tokens issued with them that have
+// not expired yet to the revocation list
+func revokeRefreshTokens(ctx context.Context, tx *sql.Tx, condition string, arg any) error {
+	now := time.Now().UTC()
+
+	// Drop revocations nobody can present anymore
+	if _, err := tx.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= $1", now); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO revoked_tokens (jti, expires_at)
+        SELECT access_jti, access_expires_at FROM refresh_tokens
+        WHERE access_expires_at > $1 AND `+condition+`
+        ON CONFLICT (jti) DO NOTHING
+    `, now, arg)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE revoked_at IS NULL AND "+condition,
+		now, arg,
+	)
+	return err
+}
+
+// rowQueryer is satisfied by both *sql.DB and *sql.Tx
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insertRefreshToken stores a refresh token, returning it with its ID and creation time
+func insertRefreshToken(ctx context.Context, conn rowQueryer, token store.RefreshToken) (store.RefreshToken, error) {
+	err := conn.QueryRowContext(ctx, `
+        INSERT INTO refresh_tokens (token_hash, family_id, user_id, access_jti, access_expires_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `, token.TokenHash, token.FamilyID, token.UserID, token.AccessJTI, token.AccessExpiresAt.UTC(), token.ExpiresAt.UTC(),
+	).Scan(&token.ID, &token.CreatedAt)
+	return token, err
+}
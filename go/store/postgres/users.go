@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-api/store"
+)
+
+// UserStore is the Postgres implementation of store.UserStore
+type UserStore struct {
+	conn *sql.DB
+}
+
+func (s *UserStore) Create(ctx context.Context, email, passwordHash, role string) (store.User, error) {
+	user := store.User{Email: email, PasswordHash: passwordHash, Role: role}
+	err := s.conn.QueryRowContext(ctx,
+		"INSERT INTO users (email, password, role) VALUES ($1, $2, $3) RETURNING id",
+		email, passwordHash, role,
+	).Scan(&user.ID)
+	return user, emailTaken(err)
+}
+
+func (s *UserStore) SetPassword(ctx context.Context, id int, passwordHash string) error {
+	result, err := s.conn.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", passwordHash, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *UserStore) SetRole(ctx context.Context, email, role string) error {
+	var exists bool
+	err := s.conn.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1)", role).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrUnknownRole
+	}
+
+	result, err := s.conn.ExecContext(ctx, "UPDATE users SET role = $1 WHERE email = $2", role, email)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *UserStore) Get(ctx context.Context, id int) (store.User, error) {
+	user := store.User{ID: id}
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT email, password, role FROM users WHERE id = $1", id,
+	).Scan(&user.Email, &user.PasswordHash, &user.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.User{}, store.ErrNotFound
+	}
+	return user, err
+}
+
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (store.User, error) {
+	user := store.User{Email: email}
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT id, password, role FROM users WHERE email = $1", email,
+	).Scan(&user.ID, &user.PasswordHash, &user.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.User{}, store.ErrNotFound
+	}
+	return user, err
+}
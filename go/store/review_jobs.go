@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Review job strategies, choosing the reviewers of each review
+const (
+	// StrategyManagerPeers assigns the employee's manager and a number of random peers
+	StrategyManagerPeers = "manager_peers"
+	// StrategyTeam assigns the employee's manager and everyone else reporting to them
+	StrategyTeam = "team"
+	// StrategyMapping assigns the reviewers listed for each employee
+	StrategyMapping = "mapping"
+)
+
+// ReviewJob is a batch of reviews created together for a cycle
+type ReviewJob struct {
+	ID      int
+	CycleID int
+	// TemplateID is zero for free-text reviews
+	TemplateID int
+	Strategy   string
+	// CreatedBy is the user who ran the job
+	CreatedBy int
+	Results   []ReviewJobResult
+	CreatedAt time.Time
+}
+
+// ReviewJobResult is what a job did for one employee
+type ReviewJobResult struct {
+	EmployeeID int
+	// ReviewID is zero when the employee was skipped or the review was removed since
+	ReviewID    int
+	ReviewerIDs []int
+	// SkipReason says why no review was created, empty when one was
+	SkipReason string
+}
+
+// ReviewJobStore persists review jobs together with the reviews they create
+type ReviewJobStore interface {
+	// Create stores the job and adds a review in the draft status for each
+	// result without a SkipReason, filling in the review IDs. Nothing is stored
+	// unless every review can be added; the errors are those of ReviewStore.Create.
+	Create(ctx context.Context, job ReviewJob) (ReviewJob, error)
+	// Get returns ErrNotFound when the job does not exist
+	Get(ctx context.Context, id int) (ReviewJob, error)
+}
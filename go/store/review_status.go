@@ -0,0 +1,79 @@
+package store
+
+import (
+	"errors"
+	"slices"
+)
+
+// Review statuses, in the order a review normally moves through them
+const (
+	// ReviewDraft is being prepared by HR and is not visible to reviewers
+	ReviewDraft = "draft"
+	// ReviewInProgress is open for reviewer feedback
+	ReviewInProgress = "in_progress"
+	// ReviewSubmitted has closed for feedback and awaits sign-off
+	ReviewSubmitted = "submitted"
+	// ReviewShared has been signed off and shared with the reviewed employee
+	ReviewShared = "shared"
+	// ReviewAcknowledged has been acknowledged by the reviewed employee
+	ReviewAcknowledged = "acknowledged"
+	// ReviewArchived is kept for the record only
+	ReviewArchived = "archived"
+)
+
+// Actors that may trigger review transitions
+const (
+	ActorAdmin    = "admin"
+	ActorReviewee = "reviewee"
+)
+
+var (
+	// ErrInvalidTransition is returned for a status change the workflow does not allow
+	ErrInvalidTransition = errors.New("invalid review status transition")
+	// ErrTransitionForbidden is returned when the actor may not trigger a valid transition
+	ErrTransitionForbidden = errors.New("actor may not perform this review status transition")
+	// ErrStatusChanged is returned when the review's status changed concurrently
+	ErrStatusChanged = errors.New("review status changed concurrently")
+)
+
+// reviewTransitions lists every allowed status change and who may trigger it
+var reviewTransitions = []struct {
+	from, to string
+	actors   []string
+}{
+	{ReviewDraft, ReviewInProgress, []string{ActorAdmin}},
+	{ReviewInProgress, ReviewSubmitted, []string{ActorAdmin}},
+	{ReviewSubmitted, ReviewInProgress, []string{ActorAdmin}},
+	{ReviewSubmitted, ReviewShared, []string{ActorAdmin}},
+	{ReviewShared, ReviewAcknowledged, []string{ActorReviewee}},
+	{ReviewDraft, ReviewArchived, []string{ActorAdmin}},
+	{ReviewShared, ReviewArchived, []string{ActorAdmin}},
+	{ReviewAcknowledged, ReviewArchived, []string{ActorAdmin}},
+}
+
+// IsReviewStatus reports whether status is a known review status
+func IsReviewStatus(status string) bool {
+	switch status {
+	case ReviewDraft, ReviewInProgress, ReviewSubmitted, ReviewShared, ReviewAcknowledged, ReviewArchived:
+		return true
+	}
+	return false
+}
+
+// CheckReviewTransition validates that actor may move a review from one status to another
+func CheckReviewTransition(from, to, actor string) error {
+	for _, transition := range reviewTransitions {
+		if transition.from == from && transition.to == to {
+			if slices.Contains(transition.actors, actor) {
+				return nil
+			}
+			return ErrTransitionForbidden
+		}
+	}
+	return ErrInvalidTransition
+}
+
+// ReviewEditable reports whether the review text and reviewers may still change
+func ReviewEditable(status string) bool {
+	return status == ReviewDraft || status == ReviewInProgress
+}
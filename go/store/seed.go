@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Seed creates the default admin and employee accounts if they do not exist yet
+func Seed(ctx context.Context, stores Stores) error {
+	// Hash passwords for admin and employees
+	adminPassword, err := hashPassword("admin")
+	if err != nil {
+		return fmt.Errorf("hashing admin password: %w", err)
+	}
+	employeePassword, err := hashPassword("employee")
+	if err != nil {
+		return fmt.Errorf("hashing employee password: %w", err)
+	}
+
+	// Seed the admin user
+	_, err = stores.Users.GetByEmail(ctx, "admin@example.com")
+	if errors.Is(err, ErrNotFound) {
+		_, err = stores.Users.Create(ctx, "admin@example.com", adminPassword, "admin")
+	}
+	if err != nil {
+		return fmt.Errorf("seeding admin user: %w", err)
+	}
+
+	// Seed employees together with their user accounts
+	seedEmployees := []Employee{
+		{Email: "employee1@example.com", Position: "Developer"},
+		{Email: "employee2@example.com", Position: "Designer"},
+	}
+	for _, employee := range seedEmployees {
+		_, err = stores.Employees.GetByEmail(ctx, employee.Email)
+		if errors.Is(err, ErrNotFound) {
+			_, err = stores.Employees.Create(ctx, employee, employeePassword)
+		}
+		if err != nil {
+			return fmt.Errorf("seeding employee %s: %w", employee.Email, err)
+		}
+	}
+	return nil
+}
+
+// hashPassword generates a bcrypt hash for the given password
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
@@ -0,0 +1,322 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrEmailTaken is returned when another employee or account already uses the email
+var ErrEmailTaken = errors.New("email already in use")
+
+// ErrConflict is returned when a write would duplicate a record that must be unique
+var ErrConflict = errors.New("record conflicts with an existing one")
+
+// ErrInvalidReference is returned when a write refers to a record that does not exist
+var ErrInvalidReference = errors.New("referenced record does not exist")
+
+// ErrNotAReviewer is returned when removing a reviewer who is not assigned to the review
+var ErrNotAReviewer = errors.New("employee is not a reviewer of the review")
+
+// ErrFeedbackExists is returned when a reviewer already has feedback on a review
+var ErrFeedbackExists = errors.New("feedback already exists")
+
+// ErrFeedbackSubmitted is returned when saving a draft of feedback that has been submitted
+var ErrFeedbackSubmitted = errors.New("feedback already submitted")
+
+// ErrVersionMismatch is returned when a record changed since the version the caller read
+var ErrVersionMismatch = errors.New("record version mismatch")
+
+// ErrManagerCycle is returned when setting a manager would make an employee report to themselves
+var ErrManagerCycle = errors.New("manager assignment would create a reporting cycle")
+
+// ErrCycleInUse is returned when deleting a review cycle that still has reviews
+var ErrCycleInUse = errors.New("review cycle has reviews")
+
+// Review cycle statuses
+const (
+	CycleDraft  = "draft"
+	CycleOpen   = "open"
+	CycleClosed = "closed"
+)
+
+// User is a login account
+type User struct {
+	ID           int
+	Email        string
+	PasswordHash string
+	Role         string
+}
+
+// Employee is a person who can be reviewed or review others
+type Employee struct {
+	ID       int
+	Email    string
+	Position string
+	// ManagerID is zero for employees without a manager
+	ManagerID int
+	// Version starts at 1 and increases with every change
+	Version   int
+	CreatedAt time.Time
+}
+
+// Report is an employee in a manager's reporting line
+type Report struct {
+	Employee
+	// Depth is 1 for direct reports, 2 for their reports and so on
+	Depth int
+}
+
+// Cycle is a review period such as a quarter or a year
+type Cycle struct {
+	ID                 int
+	Name               string
+	StartsAt           time.Time
+	SelfReviewDeadline time.Time
+	PeerReviewDeadline time.Time
+	ClosesAt           time.Time
+	Status             string
+	CreatedAt          time.Time
+}
+
+// IsClosed reports whether the cycle no longer accepts reviews at the given time
+func (c Cycle) IsClosed(now time.Time) bool {
+	return c.Status == CycleClosed || !now.Before(c.ClosesAt)
+}
+
+// HasStarted reports whether the cycle is open and past its start date
+func (c Cycle) HasStarted(now time.Time) bool {
+	return c.Status == CycleOpen && !now.Before(c.StartsAt)
+}
+
+// AcceptsFeedback reports whether reviewers may give feedback at the given time:
+// the cycle must have started and be before its peer-review deadline and close
+func (c Cycle) AcceptsFeedback(now time.Time) bool {
+	return c.HasStarted(now) && !c.IsClosed(now) && now.Before(c.PeerReviewDeadline)
+}
+
+// Review is a performance review of an employee
+type Review struct {
+	ID      int
+	CycleID int
+	// TemplateID is zero for free-text reviews
+	TemplateID        int
+	EmployeeID        int
+	EmployeeEmail     string
+	PerformanceReview string
+	Status            string
+	ReviewerIDs       []int
+	// Version starts at 1 and increases with every change, including of the reviewers
+	Version   int
+	CreatedAt time.Time
+}
+
+// Feedback is one reviewer's written feedback on a review
+type Feedback struct {
+	ID       int
+	ReviewID int
+	// ReviewerID is zero for comments carried over from before authors were recorded
+	ReviewerID    int
+	ReviewerEmail string
+	Body          string
+	Submitted     bool
+	// Answers holds the answers to the review template's questions, if it has one
+	Answers []Answer
+	// Version starts at 1 and increases with every write
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ReviewTransition records a change of a review's status
+type ReviewTransition struct {
+	ID         int
+	ReviewID   int
+	FromStatus string
+	ToStatus   string
+	ActorID    int
+	CreatedAt  time.Time
+}
+
+// ReviewFilter narrows review listings; zero values match everything
+type ReviewFilter struct {
+	CycleID    int
+	EmployeeID int
+	Status     string
+	// EmployeeIDs limits List to reviews of these employees when not nil
+	EmployeeIDs []int
+	ReviewerID  int
+	// CreatedAfter and CreatedBefore bound the creation time, exclusively
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// HasFeedback limits the listing to reviews with or without submitted
+	// feedback when not nil
+	HasFeedback *bool
+}
+
+// UserStore persists login accounts
+type UserStore interface {
+	// Create adds a login account with an already hashed password, returning
+	// ErrEmailTaken when another account uses the email
+	Create(ctx context.Context, email, passwordHash, role string) (User, error)
+	// Get returns ErrNotFound when the account does not exist
+	Get(ctx context.Context, id int) (User, error)
+	// GetByEmail returns ErrNotFound when no account uses the email
+	GetByEmail(ctx context.Context, email string) (User, error)
+	// SetPassword returns ErrNotFound when the account does not exist
+	SetPassword(ctx context.Context, id int, passwordHash string) error
+	// SetRole returns ErrNotFound when no account uses the email and
+	// ErrUnknownRole when the role does not exist
+	SetRole(ctx context.Context, email, role string) error
+}
+
+// RoleStore persists roles and their permissions
+type RoleStore interface {
+	List(ctx context.Context) ([]Role, error)
+	// Get returns ErrUnknownRole when the role does not exist
+	Get(ctx context.Context, name string) (Role, error)
+	// Permissions returns ErrUnknownRole when the role does not exist
+	Permissions(ctx context.Context, role string) ([]string, error)
+	// SetMFARequired returns ErrUnknownRole when the role does not exist
+	SetMFARequired(ctx context.Context, name string, required bool) error
+}
+
+// EmployeeStore persists employees
+type EmployeeStore interface {
+	// Create adds the employee together with an employee login account,
+	// returning ErrEmailTaken when the email is already in use
+	Create(ctx context.Context, employee Employee, passwordHash string) (Employee, error)
+	List(ctx context.Context, filter EmployeeFilter, page Page) ([]Employee, error)
+	// Get returns ErrNotFound when the employee does not exist
+	Get(ctx context.Context, id int) (Employee, error)
+	// GetByEmail returns ErrNotFound when no employee uses the email
+	GetByEmail(ctx context.Context, email string) (Employee, error)
+	// Update changes the email and position, leaving the manager as is, and returns
+	// the new version. expectedVersion is the version the caller last read, or 0 to
+	// skip the check; ErrVersionMismatch is returned if it is stale. It returns
+	// ErrNotFound when the employee does not exist and ErrEmailTaken when another
+	// employee uses the email.
+	Update(ctx context.Context, employee Employee, expectedVersion int) (int, error)
+	// Delete removes the employee together with their login account. It returns
+	// ErrNotFound when the employee does not exist and ErrVersionMismatch like Update.
+	Delete(ctx context.Context, id, expectedVersion int) error
+	// SetManager makes managerID the manager of the employee, or clears it when
+	// managerID is zero, and returns the employee's new version. It returns
+	// ErrNotFound when either employee does not exist, ErrManagerCycle when the
+	// manager reports to the employee and ErrVersionMismatch like Update.
+	SetManager(ctx context.Context, id, managerID, expectedVersion int) (int, error)
+	// Reports returns the employees reporting to the manager directly or
+	// indirectly, up to depth levels down or all of them when depth is zero
+	Reports(ctx context.Context, managerID, depth int) ([]Report, error)
+	// Import adds or updates every employee, in order, and then sets their
+	// managers; nothing is changed unless all of them succeed. It returns
+	// ErrEmailTaken when a new employee's email is used by another account,
+	// ErrNotFound when no employee uses a manager email and ErrManagerCycle when
+	// an employee would end up reporting to themselves.
+	Import(ctx context.Context, employees []EmployeeImport) ([]ImportedEmployee, error)
+}
+
+// ReviewStore persists reviews and their reviewer assignments
+type ReviewStore interface {
+	// Create adds the review in the draft status and assigns its reviewers, returning the new ID.
+	// It returns ErrInvalidReference when the cycle, template, employee or a reviewer does not
+	// exist and ErrConflict when a reviewer is listed twice.
+	Create(ctx context.Context, review Review) (int, error)
+	// Get returns ErrNotFound when the review does not exist
+	Get(ctx context.Context, id int) (Review, error)
+	// Update replaces the review text and its reviewers, leaving the assignments of
+	// reviewers who stay untouched, and returns the new version. expectedVersion is
+	// the version the caller last read, or 0 to skip the check; ErrVersionMismatch
+	// is returned if it is stale. It returns ErrNotFound when the review does not
+	// exist and otherwise the errors of Create.
+	Update(ctx context.Context, id int, performanceReview string, reviewerIDs []int, expectedVersion int) (int, error)
+	// AddReviewer assigns one more reviewer and returns the review's new version. It
+	// returns ErrNotFound when the review does not exist, ErrInvalidReference when the
+	// reviewer does not, ErrConflict when they already review it and
+	// ErrVersionMismatch like Update.
+	AddReviewer(ctx context.Context, reviewID, reviewerID, expectedVersion int) (int, error)
+	// RemoveReviewer unassigns a reviewer and returns the review's new version. It
+	// returns ErrNotFound when the review does not exist, ErrNotAReviewer when the
+	// employee does not review it and ErrVersionMismatch like Update.
+	RemoveReviewer(ctx context.Context, reviewID, reviewerID, expectedVersion int) (int, error)
+	List(ctx context.Context, filter ReviewFilter, page Page) ([]Review, error)
+	// ListPending returns in-progress reviews assigned to the reviewer that they
+	// have not submitted feedback on yet
+	ListPending(ctx context.Context, reviewerID int, filter ReviewFilter, page Page) ([]Review, error)
+	IsReviewer(ctx context.Context, reviewID, reviewerID int) (bool, error)
+	// Transition moves the review from one status to another and records who did it.
+	// It returns ErrStatusChanged if the review is no longer in the from status.
+	Transition(ctx context.Context, id int, from, to string, actorID int) (ReviewTransition, error)
+	ListTransitions(ctx context.Context, id int) ([]ReviewTransition, error)
+}
+
+// CycleStore persists review cycles
+type CycleStore interface {
+	Create(ctx context.Context, cycle Cycle) (Cycle, error)
+	// Get returns ErrNotFound when the cycle does not exist
+	Get(ctx context.Context, id int) (Cycle, error)
+	List(ctx context.Context) ([]Cycle, error)
+	// Update returns ErrNotFound when the cycle does not exist
+	Update(ctx context.Context, cycle Cycle) (Cycle, error)
+	// Delete returns ErrNotFound when the cycle does not exist and
+	// ErrCycleInUse when reviews still belong to it
+	Delete(ctx context.Context, id int) error
+}
+
+// FeedbackStore persists reviewer feedback, at most one per reviewer and review
+type FeedbackStore interface {
+	// Create returns ErrFeedbackExists when the reviewer already has feedback on the review
+	Create(ctx context.Context, feedback Feedback) (Feedback, error)
+	// Get returns ErrNotFound when the reviewer has no feedback on the review
+	Get(ctx context.Context, reviewID, reviewerID int) (Feedback, error)
+	// Update replaces the body and answers of an unsubmitted draft at expectedVersion
+	// and submits it. It returns ErrNotFound when there is no feedback,
+	// ErrFeedbackSubmitted when it is already locked and ErrVersionMismatch when it
+	// has another version.
+	Update(ctx context.Context, feedback Feedback, expectedVersion int) (Feedback, error)
+	// SaveDraft creates or overwrites unsubmitted feedback. expectedVersion is the
+	// version the caller last read, 0 when it has none; ErrVersionMismatch is
+	// returned if it is stale and ErrFeedbackSubmitted if the feedback is locked.
+	SaveDraft(ctx context.Context, feedback Feedback, expectedVersion int) (Feedback, error)
+	// Submit locks a draft at expectedVersion, returning ErrNotFound,
+	// ErrVersionMismatch or ErrFeedbackSubmitted when it cannot
+	Submit(ctx context.Context, reviewID, reviewerID, expectedVersion int) (Feedback, error)
+	// Delete removes a draft. It returns ErrNotFound when the reviewer has no feedback
+	// on the review and ErrFeedbackSubmitted when the feedback is locked.
+	Delete(ctx context.Context, reviewID, reviewerID int) error
+	// ListForReviews returns the feedback on every given review, oldest first
+	ListForReviews(ctx context.Context, reviewIDs []int) ([]Feedback, error)
+}
+
+// TemplateStore persists review templates
+type TemplateStore interface {
+	// Create stores the template with its sections and questions, assigning their IDs
+	Create(ctx context.Context, template Template) (Template, error)
+	// Get returns ErrNotFound when the template does not exist
+	Get(ctx context.Context, id int) (Template, error)
+	List(ctx context.Context) ([]Template, error)
+	// Delete returns ErrNotFound when the template does not exist and
+	// ErrTemplateInUse when reviews were created from it
+	Delete(ctx context.Context, id int) error
+}
+
+// Stores bundles one implementation of every store over the same backend
+type Stores struct {
+	Users       UserStore
+	Employees   EmployeeStore
+	Reviews     ReviewStore
+	ReviewJobs  ReviewJobStore
+	Cycles      CycleStore
+	Feedback    FeedbackStore
+	Templates   TemplateStore
+	Roles       RoleStore
+	Tokens      TokenStore
+	Resets      PasswordResetStore
+	MFA         MFAStore
+	Throttles   LoginThrottleStore
+	APIKeys     APIKeyStore
+	Idempotency IdempotencyStore
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCycleAcceptsFeedback(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	open := Cycle{
+		StartsAt:           now.Add(-24 * time.Hour),
+		SelfReviewDeadline: now.Add(24 * time.Hour),
+		PeerReviewDeadline: now.Add(48 * time.Hour),
+		ClosesAt:           now.Add(72 * time.Hour),
+		Status:             CycleOpen,
+	}
+	tests := []struct {
+		name  string
+		cycle func(c Cycle) Cycle
+		at    time.Time
+		want  bool
+	}{
+		{name: "open", at: now, want: true},
+		{name: "draft", cycle: func(c Cycle) Cycle { c.Status = CycleDraft; return c }, at: now},
+		{name: "closed", cycle: func(c Cycle) Cycle { c.Status = CycleClosed; return c }, at: now},
+		{name: "before start", at: now.Add(-48 * time.Hour)},
+		{name: "at start", at: now.Add(-24 * time.Hour), want: true},
+		{name: "after self-review deadline", at: now.Add(36 * time.Hour), want: true},
+		{name: "at peer-review deadline", at: now.Add(48 * time.Hour)},
+		{name: "after close", at: now.Add(96 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle := open
+			if tt.cycle != nil {
+				cycle = tt.cycle(cycle)
+			}
+			if got := cycle.AcceptsFeedback(tt.at); got != tt.want {
+				t.Errorf("AcceptsFeedback() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Template question types
+const (
+	// QuestionRating is answered with a rating from MinRating to MaxRating
+	QuestionRating = "rating"
+	// QuestionChoice is answered with one of the question's options
+	QuestionChoice = "choice"
+	// QuestionText is answered with free text
+	QuestionText = "text"
+)
+
+// Bounds of the rating scale
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// ErrTemplateInUse is returned when deleting a template that reviews were created from
+var ErrTemplateInUse = errors.New("review template has reviews")
+
+// Template is an admin-defined review form. Templates cannot be edited once
+// created so that answers always match the questions they were given for.
+type Template struct {
+	ID          int
+	Name        string
+	Description string
+	Sections    []TemplateSection
+	CreatedAt   time.Time
+}
+
+// TemplateSection groups related questions of a template
+type TemplateSection struct {
+	ID        int
+	Title     string
+	Questions []TemplateQuestion
+}
+
+// TemplateQuestion is a single question of a template
+type TemplateQuestion struct {
+	ID       int
+	Prompt   string
+	Type     string
+	Required bool
+	// Options lists the allowed answers of choice questions
+	Options []string
+}
+
+// Answer is a reviewer's answer to a template question. Only the field
+// matching the question type is set.
+type Answer struct {
+	QuestionID int
+	Rating     int
+	Choice     string
+	Text       string
+}
+
+// Question returns the template question with the given ID
+func (t Template) Question(id int) (TemplateQuestion, bool) {
+	for _, section := range t.Sections {
+		for _, question := range section.Questions {
+			if question.ID == id {
+				return question, true
+			}
+		}
+	}
+	return TemplateQuestion{}, false
+}
+
+// TemplateError is returned by Validate and names the part of the template at fault
+type TemplateError struct {
+	// Field is the path of the part in the request, e.g. sections[0].questions[1].options
+	Field   string
+	Message string
+}
+
+func (e *TemplateError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Validate checks that a new template is well formed, returning a *TemplateError if not
+func (t Template) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return &TemplateError{"name", "is required"}
+	}
+	if len(t.Sections) == 0 {
+		return &TemplateError{"sections", "must have at least one section"}
+	}
+	for i, section := range t.Sections {
+		if strings.TrimSpace(section.Title) == "" {
+			return &TemplateError{fmt.Sprintf("sections[%d].title", i), "is required"}
+		}
+		if len(section.Questions) == 0 {
+			return &TemplateError{fmt.Sprintf("sections[%d].questions", i), "must have at least one question"}
+		}
+		for j, question := range section.Questions {
+			if err := question.validate(fmt.Sprintf("sections[%d].questions[%d]", i, j)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (q TemplateQuestion) validate(path string) error {
+	if strings.TrimSpace(q.Prompt) == "" {
+		return &TemplateError{path + ".prompt", "is required"}
+	}
+	switch q.Type {
+	case QuestionChoice:
+		if len(q.Options) < 2 {
+			return &TemplateError{path + ".options", "must have at least two options for choice questions"}
+		}
+		for i, option := range q.Options {
+			if strings.TrimSpace(option) == "" || slices.Contains(q.Options[:i], option) {
+				return &TemplateError{path + ".options", "must be non-empty and unique"}
+			}
+		}
+	case QuestionRating, QuestionText:
+		if len(q.Options) > 0 {
+			return &TemplateError{path + ".options", fmt.Sprintf("must be empty for %s questions", q.Type)}
+		}
+	default:
+		return &TemplateError{path + ".type", fmt.Sprintf("must be one of %s, %s, %s", QuestionRating, QuestionChoice, QuestionText)}
+	}
+	return nil
+}
+
+// ValidateAnswers checks answers against the template. Drafts may leave
+// required questions unanswered; submitted feedback must answer all of them.
+func (t Template) ValidateAnswers(answers []Answer, complete bool) error {
+	answered := map[int]bool{}
+	for _, answer := range answers {
+		question, ok := t.Question(answer.QuestionID)
+		if !ok {
+			return fmt.Errorf("question %d is not part of the review template", answer.QuestionID)
+		}
+		if answered[answer.QuestionID] {
+			return fmt.Errorf("question %d is answered more than once", answer.QuestionID)
+		}
+		answered[answer.QuestionID] = true
+
+		switch question.Type {
+		case QuestionRating:
+			if answer.Rating < MinRating || answer.Rating > MaxRating {
+				return fmt.Errorf("question %d: rating must be between %d and %d", question.ID, MinRating, MaxRating)
+			}
+		case QuestionChoice:
+			if !slices.Contains(question.Options, answer.Choice) {
+				return fmt.Errorf("question %d: answer must be one of %s", question.ID, strings.Join(question.Options, ", "))
+			}
+		case QuestionText:
+			if strings.TrimSpace(answer.Text) == "" {
+				return fmt.Errorf("question %d: answer must not be empty", question.ID)
+			}
+		}
+	}
+
+	if complete {
+		for _, section := range t.Sections {
+			for _, question := range section.Questions {
+				if question.Required && !answered[question.ID] {
+					return fmt.Errorf("question %d is required", question.ID)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTokenRevoked is returned when refreshing with a revoked or expired refresh token
+	ErrTokenRevoked = errors.New("refresh token revoked or expired")
+	// ErrTokenReused is returned when a refresh token is used a second time; its
+	// whole family has been revoked by then
+	ErrTokenReused = errors.New("refresh token reused")
+)
+
+// RefreshToken is a stored refresh token. Only a hash of the token is kept.
+type RefreshToken struct {
+	ID        int
+	TokenHash string
+	// FamilyID is shared by every token rotated from the same login
+	FamilyID string
+	UserID   int
+	// AccessJTI identifies the access token issued together with this refresh token
+	AccessJTI       string
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+	// UsedAt is zero until the token is rotated
+	UsedAt time.Time
+	// RevokedAt is zero unless the token was revoked
+	RevokedAt time.Time
+	CreatedAt time.Time
+}
+
+// TokenStore persists refresh tokens and revoked access tokens
+type TokenStore interface {
+	// Create stores the refresh token issued at login, starting a new family
+	Create(ctx context.Context, token RefreshToken) error
+	// Rotate marks the refresh token with oldHash as used and stores next in its
+	// family and for its user, returning next with those filled in. It returns
+	// ErrNotFound for unknown tokens, ErrTokenRevoked for revoked or expired ones
+	// and ErrTokenReused, after revoking the family, for tokens already used.
+	Rotate(ctx context.Context, oldHash string, next RefreshToken) (RefreshToken, error)
+	// RevokeFamily revokes every refresh token of the family and their access tokens
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeUser revokes every refresh and access token of the user
+	RevokeUser(ctx context.Context, userID int) error
+	// IsRevoked reports whether the access token with the jti was revoked
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
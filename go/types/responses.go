@@ -2,27 +2,207 @@ package types
 
 // EmployeeResponse represents an employee in API responses
 type EmployeeResponse struct {
-	ID       int    `json:"id"`
-	Email    string `json:"email"`
-	Position string `json:"position"`
+	ID        int    `json:"id"`
+	Email     string `json:"email"`
+	Position  string `json:"position"`
+	ManagerID int    `json:"manager_id,omitempty"`
+	// Version increases with every change and is what the ETag header carries
+	Version   int    `json:"version"`
+	CreatedAt string `json:"created_at"`
+}
+
+// EmployeeListResponse is one page of employees
+type EmployeeListResponse struct {
+	Items []EmployeeResponse `json:"items"`
+	// NextCursor is passed as ?cursor= to fetch the next page; it is absent on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ErrorResponse is the body of every error response, an RFC 7807 problem
+// document (application/problem+json) with the fields below as extensions
+type ErrorResponse struct {
+	// Type is always about:blank, so Title is the HTTP status text
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	// Code identifies the error, e.g. review_not_found; see the README for the list
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details holds error-specific data, such as the field that was invalid
+	Details map[string]any `json:"details,omitempty"`
+	// RequestID matches the X-Request-ID response header and the server logs
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// APIKeyResponse represents a personal API key
+type APIKeyResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Key is only returned when the key is created
+	Key         string   `json:"key,omitempty"`
+	Prefix      string   `json:"prefix"`
+	Permissions []string `json:"permissions"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
+	LastUsedAt  string   `json:"last_used_at,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// LockoutResponse represents an account or client address refused after failed logins
+type LockoutResponse struct {
+	Email       string `json:"email,omitempty"`
+	IP          string `json:"ip,omitempty"`
+	Failures    int    `json:"failures"`
+	LockedUntil string `json:"locked_until"`
+}
+
+// RoleResponse represents a role and the permissions it grants
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	MFARequired bool     `json:"mfa_required"`
+}
+
+// ReportResponse represents an employee in a manager's reporting line
+type ReportResponse struct {
+	ID        int    `json:"id"`
+	Email     string `json:"email"`
+	Position  string `json:"position"`
+	ManagerID int    `json:"manager_id"`
+	// Depth is 1 for direct reports, 2 for their reports and so on
+	Depth int `json:"depth"`
 }
 
 // ReviewResponse represents a review in API responses
 type ReviewResponse struct {
-	ID                int      `json:"id"`
-	EmployeeID        int      `json:"employee_id"`
-	EmployeeEmail     string   `json:"employee_email"`
-	PerformanceReview string   `json:"performance_review"`
-	Comments          []string `json:"comments"`
-	ReviewerIDs       []int    `json:"reviewer_ids"`
-	CreatedAt         string   `json:"created_at"`
+	ID                int                `json:"id"`
+	CycleID           int                `json:"cycle_id"`
+	TemplateID        int                `json:"template_id,omitempty"`
+	EmployeeID        int                `json:"employee_id"`
+	EmployeeEmail     string             `json:"employee_email"`
+	PerformanceReview string             `json:"performance_review"`
+	Status            string             `json:"status"`
+	Comments          []FeedbackResponse `json:"comments"`
+	// Ratings averages the submitted rating answers per template question
+	Ratings     []QuestionRatingResponse `json:"ratings,omitempty"`
+	ReviewerIDs []int                    `json:"reviewer_ids"`
+	Version     int                      `json:"version"`
+	CreatedAt   string                   `json:"created_at"`
+}
+
+// ReviewListResponse is one page of reviews
+type ReviewListResponse struct {
+	Items []ReviewResponse `json:"items"`
+	// NextCursor is passed as ?cursor= to fetch the next page; it is absent on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// FeedbackResponse represents one reviewer's feedback on a review
+type FeedbackResponse struct {
+	ID            int    `json:"id"`
+	ReviewID      int    `json:"review_id"`
+	ReviewerID    int    `json:"reviewer_id,omitempty"`
+	ReviewerEmail string `json:"reviewer_email,omitempty"`
+	Body          string `json:"body"`
+	Submitted     bool   `json:"submitted"`
+	// Answers holds the answers to the review template's questions, if it has one
+	Answers   []AnswerResponse `json:"answers,omitempty"`
+	Version   int              `json:"version"`
+	CreatedAt string           `json:"created_at"`
+	UpdatedAt string           `json:"updated_at"`
+}
+
+// AnswerResponse represents an answer to a template question; only the field
+// matching the question type is set
+type AnswerResponse struct {
+	QuestionID int    `json:"question_id"`
+	Rating     int    `json:"rating,omitempty"`
+	Choice     string `json:"choice,omitempty"`
+	Text       string `json:"text,omitempty"`
+}
+
+// QuestionRatingResponse represents the average of the submitted ratings for a question
+type QuestionRatingResponse struct {
+	QuestionID int     `json:"question_id"`
+	Average    float64 `json:"average"`
+	Count      int     `json:"count"`
+}
+
+// TemplateResponse represents a review template in API responses
+type TemplateResponse struct {
+	ID          int                       `json:"id"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Sections    []TemplateSectionResponse `json:"sections"`
+	CreatedAt   string                    `json:"created_at"`
+}
+
+// TemplateSectionResponse represents a section of a review template
+type TemplateSectionResponse struct {
+	ID        int                        `json:"id"`
+	Title     string                     `json:"title"`
+	Questions []TemplateQuestionResponse `json:"questions"`
+}
+
+// TemplateQuestionResponse represents a question of a review template
+type TemplateQuestionResponse struct {
+	ID       int      `json:"id"`
+	Prompt   string   `json:"prompt"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// ReceivedReviewResponse represents a review shared with the employee it is about
+type ReceivedReviewResponse struct {
+	ID                int                      `json:"id"`
+	CycleID           int                      `json:"cycle_id"`
+	TemplateID        int                      `json:"template_id,omitempty"`
+	PerformanceReview string                   `json:"performance_review"`
+	Status            string                   `json:"status"`
+	Comments          []FeedbackResponse       `json:"comments"`
+	Ratings           []QuestionRatingResponse `json:"ratings,omitempty"`
+	CreatedAt         string                   `json:"created_at"`
+}
+
+// ReviewTransitionResponse represents a change of a review's status
+type ReviewTransitionResponse struct {
+	ID         int    `json:"id"`
+	ReviewID   int    `json:"review_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	ActorID    int    `json:"actor_id,omitempty"`
+	CreatedAt  string `json:"created_at"`
 }
 
 // AssignedReviewResponse represents a review assigned to an employee
 type AssignedReviewResponse struct {
 	ID                int    `json:"id"`
+	CycleID           int    `json:"cycle_id"`
+	TemplateID        int    `json:"template_id,omitempty"`
 	EmployeeEmail     string `json:"employee_email"`
 	PerformanceReview string `json:"performance_review"`
+	CreatedAt         string `json:"created_at"`
+}
+
+// AssignedReviewListResponse is one page of reviews assigned to an employee
+type AssignedReviewListResponse struct {
+	Items []AssignedReviewResponse `json:"items"`
+	// NextCursor is passed as ?cursor= to fetch the next page; it is absent on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CycleResponse represents a review cycle in API responses
+type CycleResponse struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	StartsAt           string `json:"starts_at"`
+	SelfReviewDeadline string `json:"self_review_deadline"`
+	PeerReviewDeadline string `json:"peer_review_deadline"`
+	ClosesAt           string `json:"closes_at"`
+	Status             string `json:"status"`
+	CreatedAt          string `json:"created_at"`
 }
 
 // CreateEmployeeResponse represents the response when creating an employee
@@ -31,6 +211,55 @@ type CreateEmployeeResponse struct {
 	Email      string `json:"email"`
 }
 
+// EmployeeImportResponse reports what an import did, or would do in a dry run, to each row
+type EmployeeImportResponse struct {
+	DryRun    bool                        `json:"dry_run"`
+	Created   int                         `json:"created"`
+	Updated   int                         `json:"updated"`
+	Unchanged int                         `json:"unchanged"`
+	Invalid   int                         `json:"invalid"`
+	Rows      []EmployeeImportRowResponse `json:"rows"`
+}
+
+// EmployeeImportRowResponse is the outcome of one row of an import
+type EmployeeImportRowResponse struct {
+	// Line is where the row starts in the uploaded file
+	Line  int    `json:"line"`
+	Email string `json:"email"`
+	// Result is created, updated, unchanged or invalid
+	Result     string `json:"result"`
+	EmployeeID int    `json:"employee_id,omitempty"`
+	// Invited is set when an email to choose a password was sent
+	Invited bool `json:"invited,omitempty"`
+	// Errors maps each invalid field to what is wrong with it, using row when the row could not be read
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ReviewJobResponse is a batch of reviews created together and what was done for each employee
+type ReviewJobResponse struct {
+	ID         int    `json:"id"`
+	CycleID    int    `json:"cycle_id"`
+	TemplateID int    `json:"template_id,omitempty"`
+	Strategy   string `json:"strategy"`
+	// CreatedBy is the user who ran the job
+	CreatedBy int `json:"created_by,omitempty"`
+	// Created and Skipped count the employees who got a review and those who did not
+	Created   int                       `json:"created"`
+	Skipped   int                       `json:"skipped"`
+	Results   []ReviewJobResultResponse `json:"results"`
+	CreatedAt string                    `json:"created_at"`
+}
+
+// ReviewJobResultResponse is what a review job did for one employee
+type ReviewJobResultResponse struct {
+	EmployeeID int `json:"employee_id"`
+	// ReviewID is absent when the employee was skipped or the review was removed since
+	ReviewID    int   `json:"review_id,omitempty"`
+	ReviewerIDs []int `json:"reviewer_ids"`
+	// SkipReason says why no review was created
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
 // CreateReviewResponse represents the response when creating a review
 type CreateReviewResponse struct {
 	ReviewID int `json:"review_id"`
@@ -41,7 +270,36 @@ type MessageResponse struct {
 	Message string `json:"message"`
 }
 
-// TokenResponse represents a JWT token response
+// TokenResponse represents a JWT access token and the refresh token to renew it
 type TokenResponse struct {
-	Token string `json:"token"`
-}
\ No newline at end of file
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int `json:"expires_in"`
+	// RecoveryCodes are only returned when two-factor enrolment completes at login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// MFAChallengeResponse is returned by login instead of tokens when a second factor is needed
+type MFAChallengeResponse struct {
+	// MFARequired is set when the user must enter an authenticator or recovery code
+	MFARequired bool `json:"mfa_required"`
+	// EnrollmentRequired is set when the user's role requires two-factor
+	// authentication and the user has to enrol first
+	EnrollmentRequired bool   `json:"mfa_enrollment_required"`
+	MFAToken           string `json:"mfa_token"`
+	// ExpiresIn is the lifetime of the challenge token in seconds
+	ExpiresIn int `json:"expires_in"`
+}
+
+// TOTPEnrollmentResponse carries a new authenticator secret to confirm with a code
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// provisioning URI to show as a QR code
+	URI string `json:"uri"`
+}
+
+// RecoveryCodesResponse lists single-use recovery codes; they are only shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
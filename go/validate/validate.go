@@ -0,0 +1,201 @@
+// Package validate checks request payloads against rules declared in struct
+// tags, e.g. `json:"email" validate:"required,email,max=254"`.
+//
+// Rules are separated by commas and checked in order; the first one a field
+// fails is reported under its JSON name. Nested structs and slices of structs
+// are checked too, with paths such as sections[0].questions[1].prompt.
+//
+//	required   not the zero value; strings must not be blank and slices not empty
+//	email      a bare email address such as jane@example.com
+//	min=N      at least N characters, items or, for numbers, the value N
+//	max=N      at most N characters, items or, for numbers, the value N
+//	unique     no item of a slice occurs twice
+//	oneof=a b  one of the space-separated values
+//
+// Rules other than required pass for empty values, so optional fields only
+// need to be valid when given.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Errors maps the JSON path of each invalid field to what is wrong with it
+type Errors map[string]string
+
+// Add records a problem with a field unless one is already recorded for it,
+// for checks that need more than one field or a lookup
+func (e Errors) Add(field, message string) {
+	if _, ok := e[field]; !ok {
+		e[field] = message
+	}
+}
+
+// Error lists the problems in field order
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	problems := make([]string, len(fields))
+	for i, field := range fields {
+		problems[i] = field + " " + e[field]
+	}
+	return strings.Join(problems, "; ")
+}
+
+// Struct checks the tagged fields of the struct v points to, returning an
+// empty map when all of them are valid
+func Struct(v any) Errors {
+	errs := Errors{}
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() == reflect.Struct {
+		checkStruct(errs, "", value)
+	}
+	return errs
+}
+
+func checkStruct(errs Errors, prefix string, value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		path := prefix + name
+		fieldValue := value.Field(i)
+
+		if tag := field.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				if message := check(rule, fieldValue); message != "" {
+					errs.Add(path, message)
+					break
+				}
+			}
+		}
+		dive(errs, path, fieldValue)
+	}
+}
+
+// dive checks the fields of nested structs, including those in slices
+func dive(errs Errors, path string, value reflect.Value) {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() != timeType {
+			checkStruct(errs, path+".", value)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			dive(errs, fmt.Sprintf("%s[%d]", path, i), value.Index(i))
+		}
+	}
+}
+
+// jsonName returns the name the field has in JSON
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// check applies one rule, returning what is wrong or "" when the value passes
+func check(rule string, value reflect.Value) string {
+	name, param, _ := strings.Cut(rule, "=")
+	if name == "required" {
+		if isEmpty(value) {
+			return "is required"
+		}
+		return ""
+	}
+	if isEmpty(value) {
+		return ""
+	}
+	value = reflect.Indirect(value)
+
+	switch name {
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "must be a valid email address"
+		}
+	case "min", "max":
+		return checkBound(name, param, value)
+	case "unique":
+		for i := 1; i < value.Len(); i++ {
+			for j := 0; j < i; j++ {
+				if value.Index(i).Interface() == value.Index(j).Interface() {
+					return "must not contain duplicates"
+				}
+			}
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		if !slices.Contains(options, fmt.Sprint(value.Interface())) {
+			return "must be one of " + strings.Join(options, ", ")
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+	return ""
+}
+
+// checkBound applies min and max to lengths of strings and slices and to numbers
+func checkBound(name, param string, value reflect.Value) string {
+	bound, err := strconv.Atoi(param)
+	if err != nil {
+		panic(fmt.Sprintf("validate: %s needs a number, got %q", name, param))
+	}
+	tooSmall := name == "min"
+
+	var n int
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = utf8.RuneCountInString(value.String()), " characters"
+	case reflect.Slice, reflect.Map:
+		n, unit = value.Len(), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = int(value.Int())
+	default:
+		panic(fmt.Sprintf("validate: %s does not apply to %s", name, value.Kind()))
+	}
+
+	if tooSmall && n < bound {
+		return fmt.Sprintf("must be at least %d%s", bound, unit)
+	}
+	if !tooSmall && n > bound {
+		return fmt.Sprintf("must be at most %d%s", bound, unit)
+	}
+	return ""
+}
+
+// isEmpty reports whether a value counts as not given
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil()
+	default:
+		return value.IsZero()
+	}
+}